@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/korylprince/jettison/lib/cache"
+	"github.com/korylprince/jettison/lib/file"
+)
+
+//staged is a file downloaded to a temporary path, waiting to replace path
+type staged struct {
+	path    string
+	tmp     string
+	backup  string //a link to or copy of the previous file, made during commit. Empty if there was none
+	renamed bool   //tmp has replaced path
+	entry   *file.Entry
+}
+
+//update is a file whose content is unchanged but whose metadata may need to be updated
+type update struct {
+	path  string
+	entry *file.Entry
+	prev  *fileMetadata //the metadata before it was updated. nil if not updated yet
+}
+
+//transaction replaces a group's files and updates their metadata together,
+//restoring the previous files and metadata if any step fails
+type transaction struct {
+	group   string
+	fetcher Fetcher
+	files   []*staged
+	updates []*update
+}
+
+//download is a file that needs to be downloaded
+type download struct {
+	path  string
+	entry *file.Entry
+}
+
+//stage downloads d with fetcher to a temporary file next to its path and applies its metadata.
+//If the file was downloaded, the returned *staged is not nil even if an error occurred so it can be cleaned up.
+//Errors that won't be resolved by retrying are permanent
+func stage(fetcher Fetcher, d *download) (*staged, error) {
+	tmp, err := Download(fetcher, d.path, d.entry.Hash, d.entry.Digest)
+	if err != nil {
+		return nil, err
+	}
+	f := &staged{path: d.path, tmp: tmp, entry: d.entry}
+
+	if _, err = ApplyMetadata(tmp, d.entry); err != nil {
+		return f, permanent(err)
+	}
+	log.Printf("Download: Path: %s, Hash: %d\n", d.path, d.entry.Hash)
+	return f, nil
+}
+
+//stage stages downloads with up to workers downloads at once, retrying failed downloads with r,
+//and returns the first error that occurred. No new downloads are started after an error.
+//Staged files are committed in path order
+func (t *transaction) stage(downloads []*download, workers int, r *retrier) error {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		first error
+	)
+
+	jobs := make(chan *download)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range jobs {
+				var f *staged
+				err := r.do("Download "+d.path, func() error {
+					var err error
+					f, err = stage(t.fetcher, d)
+					return err
+				})
+				mu.Lock()
+				if f != nil {
+					t.files = append(t.files, f)
+				}
+				if err != nil && first == nil {
+					first = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, d := range downloads {
+		mu.Lock()
+		failed := first != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		jobs <- d
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(t.files, func(i, j int) bool { return t.files[i].path < t.files[j].path })
+
+	return first
+}
+
+//backupFile links path to backup, or copies it if it can't be linked, leaving path in place
+func backupFile(path, backup string) error {
+	if err := os.Link(path, backup); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode()&os.ModePerm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if cErr := dst.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(backup)
+	}
+	return err
+}
+
+//commit moves every staged file into place, keeping a backup of any previous file,
+//updates the metadata of unchanged files, then updates the cache.
+//Each file is replaced with a single rename, so its path always exists.
+//If any step fails, the transaction is rolled back and an error is returned
+func (t *transaction) commit(c cache.Cache) error {
+	for _, f := range t.files {
+		if _, err := os.Lstat(f.path); err == nil {
+			f.backup = f.tmp + ".bak"
+			if err = backupFile(f.path, f.backup); err != nil {
+				f.backup = ""
+				t.rollback(c)
+				return fmt.Errorf("Error backing up file %s: %v", f.path, err)
+			}
+		}
+		if err := os.Rename(f.tmp, f.path); err != nil {
+			t.rollback(c)
+			return fmt.Errorf("Error replacing file %s: %v", f.path, err)
+		}
+		f.renamed = true
+	}
+
+	for _, u := range t.updates {
+		prev, err := readMetadata(u.path)
+		if err != nil {
+			t.rollback(c)
+			return err
+		}
+		changed, err := ApplyMetadata(u.path, u.entry)
+		if changed || err != nil {
+			//a failed update may have partially applied
+			u.prev = prev
+		}
+		if err != nil {
+			t.rollback(c)
+			return err
+		}
+		if changed {
+			log.Printf("Metadata: Path: %s, Mode: %v, Owner: %s, Group: %s\n", u.path, u.entry.Mode, u.entry.Owner, u.entry.Group)
+		}
+	}
+
+	for _, f := range t.files {
+		info, err := os.Stat(f.path)
+		if err != nil {
+			t.rollback(c)
+			return fmt.Errorf("Error reading %s: %v", f.path, err)
+		}
+		err = c.Put(f.path, &cache.Entry{Hash: f.entry.Hash, Digest: f.entry.Digest, ModTime: info.ModTime(), Size: info.Size()})
+		if err != nil {
+			t.rollback(c)
+			return fmt.Errorf("Cache.Put error: %v", err)
+		}
+	}
+
+	for _, f := range t.files {
+		if f.backup != "" {
+			if err := os.Remove(f.backup); err != nil {
+				log.Printf("Commit: Error removing backup %s: %v\n", f.backup, err)
+			}
+		}
+	}
+
+	if len(t.files) > 0 {
+		log.Printf("Commit: Group: %s, Files: %d\n", t.group, len(t.files))
+	}
+	return nil
+}
+
+//rollback restores the previous files and metadata and removes any temporary files.
+//Cache entries of replaced paths are removed so they will be downloaded again
+func (t *transaction) rollback(c cache.Cache) {
+	for i := len(t.updates) - 1; i >= 0; i-- {
+		u := t.updates[i]
+		if u.prev == nil {
+			continue
+		}
+		if err := restoreMetadata(u.path, u.prev); err != nil {
+			log.Println("Rollback: Error:", err)
+		}
+	}
+
+	for i := len(t.files) - 1; i >= 0; i-- {
+		f := t.files[i]
+		if f.backup != "" && !f.renamed {
+			//the previous file was never replaced
+			os.Remove(f.backup)
+		} else if f.backup != "" {
+			if err := os.Rename(f.backup, f.path); err != nil {
+				log.Printf("Rollback: Error restoring %s: %v\n", f.path, err)
+			}
+		} else if f.renamed {
+			if err := os.Remove(f.path); err != nil {
+				log.Printf("Rollback: Error removing %s: %v\n", f.path, err)
+			}
+		}
+		if !f.renamed {
+			os.Remove(f.tmp)
+			continue
+		}
+		if err := c.Delete(f.path); err != nil {
+			log.Printf("Rollback: Error removing cache entry %s: %v\n", f.path, err)
+		}
+	}
+
+	if len(t.files) > 0 {
+		log.Printf("Rollback: Group: %s, Files: %d\n", t.group, len(t.files))
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korylprince/jettison/lib/cache"
+	"github.com/korylprince/jettison/lib/file"
+)
+
+//stageFile writes content to a temporary file next to path and returns it staged
+func stageFile(t *testing.T, path, content string) *staged {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &staged{path: path, tmp: tmp, entry: &file.Entry{Hash: 1}}
+}
+
+func readFile(t *testing.T, path string) string {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func TestTransactionCommit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := cache.NewBoltCache(filepath.Join(dir, "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	existing, created := filepath.Join(dir, "existing"), filepath.Join(dir, "created")
+	if err = ioutil.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := &transaction{group: "test", files: []*staged{stageFile(t, existing, "new"), stageFile(t, created, "created")}}
+	if err = tx.commit(c); err != nil {
+		t.Fatal("commit:", err)
+	}
+
+	if s := readFile(t, existing); s != "new" {
+		t.Errorf("existing: expected new, got %s", s)
+	}
+	if s := readFile(t, created); s != "created" {
+		t.Errorf("created: expected created, got %s", s)
+	}
+	if infos, _ := ioutil.ReadDir(dir); len(infos) != 3 {
+		t.Errorf("expected backups and temporary files to be removed, got %d files", len(infos))
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := cache.NewBoltCache(filepath.Join(dir, "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	existing, blocked := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err = ioutil.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	//a non-empty directory can't be replaced, failing the commit
+	if err = os.MkdirAll(filepath.Join(blocked, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := &transaction{group: "test", files: []*staged{stageFile(t, existing, "new"), stageFile(t, blocked, "new")}}
+	if err = tx.commit(c); err == nil {
+		t.Fatal("commit: expected error")
+	}
+
+	if s := readFile(t, existing); s != "old" {
+		t.Errorf("existing: expected old, got %s", s)
+	}
+	if infos, _ := ioutil.ReadDir(dir); len(infos) != 3 {
+		t.Errorf("expected backups and temporary files to be removed, got %d files", len(infos))
+	}
+}
+
+func TestTransactionRollbackMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := cache.NewBoltCache(filepath.Join(dir, "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	unchanged := filepath.Join(dir, "unchanged")
+	if err = ioutil.WriteFile(unchanged, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	//the missing file's update fails after the first file's mode was changed
+	tx := &transaction{group: "test", updates: []*update{
+		{path: unchanged, entry: &file.Entry{Hash: 1, Mode: 0600}},
+		{path: filepath.Join(dir, "missing"), entry: &file.Entry{Hash: 2, Mode: 0600}},
+	}}
+	if err = tx.commit(c); err == nil {
+		t.Fatal("commit: expected error")
+	}
+
+	info, err := os.Stat(unchanged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("unchanged: expected mode %v, got %v", os.FileMode(0644), info.Mode().Perm())
+	}
+}
@@ -8,6 +8,12 @@ import (
 	"github.com/kelseyhightower/envconfig"
 )
 
+//Transports
+const (
+	TransportHTTP = "http" //files are downloaded from the HTTP server (default)
+	TransportGRPC = "grpc" //files are downloaded over the RPC connection
+)
+
 //Config stores configuration from the environment
 type Config struct {
 	GroupStr     string `envconfig:"GROUPS"`
@@ -17,10 +23,31 @@ type Config struct {
 
 	ReportInterval time.Duration //in seconds
 	CheckInterval  time.Duration //in seconds
+	VerifyInterval time.Duration //in seconds
 
-	HTTPServerAddr string
+	Transport      string
+	HTTPServerAddr string //only required for the http transport
 	RPCServerAddr  string
 	CachePath      string
+
+	DownloadWorkers int //number of files downloaded at once
+
+	TLS     bool   //use TLS for RPCs and downloads
+	TLSCA   string //PEM CA path. If set, only servers with certificates signed by it are trusted instead of the system roots
+	TLSCert string //PEM client certificate path, for servers that require client certificates
+	TLSKey  string //PEM client key path
+
+	Token string //enrollment token identifying the client, for servers with a policy and without client certificates
+
+	RetryAttempts int           //attempts made for each download or request
+	RetryBudget   int           //retries allowed across all downloads in a single pass
+	RetryDelay    time.Duration //in seconds, doubled after each attempt
+	RetryMaxDelay time.Duration //in seconds
+
+	QuarantinePath   string //files removed from a group with the quarantine delete policy are moved here
+	MaxDeletePercent int    //removal is refused if more than this percent of a group's files, and more than a few files, would be removed at once
+
+	UnlimitedDropRemoval bool //remove every file of groups that are no longer configured, ignoring MaxDeletePercent
 }
 
 //ParseEnv parses a Config from the environment, returning an error if one occurred
@@ -49,7 +76,17 @@ func ParseEnv() (*Config, error) {
 		config.CheckInterval = 10 * 60
 
 	}
-	if config.HTTPServerAddr == "" {
+	if config.VerifyInterval == 0 {
+		config.VerifyInterval = 60 * 60
+	}
+	switch config.Transport {
+	case "":
+		config.Transport = TransportHTTP
+	case TransportHTTP, TransportGRPC:
+	default:
+		return nil, fmt.Errorf("JETTISON_TRANSPORT must be %s or %s", TransportHTTP, TransportGRPC)
+	}
+	if config.Transport == TransportHTTP && config.HTTPServerAddr == "" {
 		return nil, fmt.Errorf("JETTISON_HTTPSERVERADDR must be configured")
 	}
 	if config.RPCServerAddr == "" {
@@ -58,6 +95,36 @@ func ParseEnv() (*Config, error) {
 	if config.CachePath == "" {
 		return nil, fmt.Errorf("JETTISON_CACHEPATH must be configured")
 	}
+	if (config.TLSCert == "") != (config.TLSKey == "") {
+		return nil, fmt.Errorf("JETTISON_TLSCERT and JETTISON_TLSKEY must be configured together")
+	}
+	if !config.TLS && (config.TLSCA != "" || config.TLSCert != "") {
+		return nil, fmt.Errorf("JETTISON_TLS must be enabled to use JETTISON_TLSCA or JETTISON_TLSCERT")
+	}
+	if config.DownloadWorkers <= 0 {
+		config.DownloadWorkers = 4
+	}
+	if config.RetryAttempts <= 0 {
+		config.RetryAttempts = 5
+	}
+	if config.RetryBudget == 0 {
+		config.RetryBudget = 100
+	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = 1
+	}
+	if config.RetryMaxDelay == 0 {
+		config.RetryMaxDelay = 60
+	}
+	if config.RetryMaxDelay < config.RetryDelay {
+		return nil, fmt.Errorf("JETTISON_RETRYMAXDELAY must not be less than JETTISON_RETRYDELAY")
+	}
+	if config.MaxDeletePercent == 0 {
+		config.MaxDeletePercent = 50
+	}
+	if config.MaxDeletePercent < 0 || config.MaxDeletePercent > 100 {
+		return nil, fmt.Errorf("JETTISON_MAXDELETEPERCENT must be between 1 and 100")
+	}
 
 	return config, nil
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/korylprince/jettison/lib/rpc"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+//Metadata returns the metadata sent with every RPC, identifying the client to the server
+func Metadata(config *Config) metadata.MD {
+	md := metadata.MD{
+		"groups":          config.Groups,
+		"hardware_addr":   {config.HardwareAddr},
+		"location":        {config.Location},
+		"report_interval": {strconv.Itoa(int(config.ReportInterval))},
+	}
+	if config.Token != "" {
+		md["token"] = []string{config.Token}
+	}
+	return md
+}
+
+//establishedAfter is how long a stream must stay up to be considered established if it hasn't received a notification
+const establishedAfter = 30 * time.Second
+
+//EventService maintains the Events stream, running the NotificationService and ReportService on it.
+//If the stream fails, it's re-created with backoff. The backoff is only reset once the new stream is established:
+//it has received a notification or stayed up for establishedAfter. The first established stream after any failed attempt,
+//including at startup, rescans all groups since notifications may have been missed or the initial check may have failed.
+//Streams the server closes right away don't reset the backoff or cause rescans.
+//EventService never returns
+func EventService(config *Config, conn *grpc.ClientConn, fileService *FileService) {
+	md := Metadata(config)
+	client := rpc.NewEventsClient(conn)
+
+	delay := config.RetryDelay * time.Second
+	missed := false //a stream failed, so notifications may have been missed
+	for {
+		ctx, cancel := context.WithCancel(metadata.NewContext(context.Background(), md))
+		stream, err := client.Stream(ctx)
+		if err == nil {
+			log.Println("Events: Stream connected")
+
+			established := make(chan struct{})
+			once := new(sync.Once)
+			establish := func() { once.Do(func() { close(established) }) }
+			timer := time.AfterFunc(establishedAfter, establish)
+
+			errs := make(chan error, 2)
+			go func() { errs <- NotificationService(fileService, stream, establish) }()
+			go func() { errs <- ReportService(ctx, config, stream, fileService) }()
+
+			select {
+			case <-established:
+				log.Println("Events: Stream established")
+				delay = config.RetryDelay * time.Second
+				if missed {
+					fileService.Scan(config.Groups...)
+					missed = false
+				}
+				err = <-errs
+			case err = <-errs:
+			}
+			timer.Stop()
+			cancel()
+			<-errs
+		}
+		cancel()
+		missed = true
+
+		var wait time.Duration
+		wait, delay = backoff(delay, config.RetryMaxDelay*time.Second)
+		log.Printf("Events: Stream error, reconnecting in %v: %v\n", wait, err)
+		time.Sleep(wait)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/korylprince/jettison/lib/rpc"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+//Fetcher retrieves file content by hash.
+//Errors that won't be resolved by retrying, e.g. a missing file, are permanent
+type Fetcher interface {
+	Fetch(hash uint64) (io.ReadCloser, error)
+}
+
+//HTTPFetcher is a Fetcher that downloads files from the server's HTTP /file/ endpoint
+type HTTPFetcher struct {
+	base   string //scheme://addr
+	header http.Header
+	client *http.Client
+}
+
+//NewHTTPFetcher returns a new HTTPFetcher for the configured server, using HTTPS if tlsConfig is not nil.
+//Requests identify the client with the same information as RPC metadata
+func NewHTTPFetcher(config *Config, tlsConfig *tls.Config) *HTTPFetcher {
+	f := &HTTPFetcher{base: "http://" + config.HTTPServerAddr, header: make(http.Header), client: http.DefaultClient}
+	if tlsConfig != nil {
+		f.base = "https://" + config.HTTPServerAddr
+		f.client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig}}
+	}
+
+	f.header.Set("X-Jettison-Hardware-Addr", config.HardwareAddr)
+	f.header.Set("X-Jettison-Location", config.Location)
+	if config.Token != "" {
+		f.header.Set("Authorization", "Bearer "+config.Token)
+	}
+	return f
+}
+
+//Fetch satisfies Fetcher
+func (f *HTTPFetcher) Fetch(hash uint64) (io.ReadCloser, error) {
+	url := fmt.Sprintf("%s/file/%d", f.base, hash)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting %s: %v", url, err)
+	}
+	req.Header = f.header
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting %s: %v", url, err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		return nil, permanent(fmt.Errorf("Error getting %s: %s", url, resp.Status))
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Error getting %s: %s", url, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+//GRPCFetcher is a Fetcher that downloads files with the FileSet Download RPC
+type GRPCFetcher struct {
+	Client rpc.FileSetClient
+	MD     metadata.MD //sent with each RPC
+}
+
+//Fetch satisfies Fetcher
+func (f *GRPCFetcher) Fetch(hash uint64) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(metadata.NewContext(context.Background(), f.MD))
+	stream, err := f.Client.Download(ctx, &rpc.FileRequest{Hash: hash})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("Error downloading %d: %v", hash, err)
+	}
+	return &chunkReader{stream: stream, hash: hash, cancel: cancel}, nil
+}
+
+//chunkReader is an io.ReadCloser over a FileSet Download stream
+type chunkReader struct {
+	stream rpc.FileSet_DownloadClient
+	hash   uint64
+	buf    []byte
+	cancel context.CancelFunc
+}
+
+//Read satisfies io.Reader
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.stream.Recv()
+		if err == io.EOF {
+			return 0, io.EOF
+		}
+		if c := grpc.Code(err); c == codes.NotFound || c == codes.PermissionDenied {
+			return 0, permanent(fmt.Errorf("Error downloading %d: %v", r.hash, err))
+		}
+		if err != nil {
+			return 0, fmt.Errorf("Error downloading %d: %v", r.hash, err)
+		}
+		r.buf = chunk.GetData()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+//Close satisfies io.Closer, cancelling the stream
+func (r *chunkReader) Close() error {
+	r.cancel()
+	return nil
+}
@@ -1,10 +1,11 @@
 package main
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/korylprince/jettison/lib/cache"
 	"github.com/korylprince/jettison/lib/file"
@@ -21,24 +23,34 @@ import (
 
 //FileService manages the local files for the jettison client
 type FileService struct {
-	config *Config
-	cache  cache.Cache
-	client rpc.FileSetClient
-	sets   map[string]*file.VersionedSet //group:VersionedSet
-	mu     *sync.RWMutex
+	config  *Config
+	cache   cache.Cache
+	client  rpc.FileSetClient
+	fetcher Fetcher
+	sets    map[string]*file.VersionedSet //group:VersionedSet
+	drift   map[string]uint64             //group:files repaired in the last verification pass
+	mu      *sync.RWMutex
 
 	scan chan []string //chan groups
 }
 
-//NewFileService returns a new FileService
-func NewFileService(config *Config, c cache.Cache, client rpc.FileSetClient) *FileService {
+//NewFileService returns a new FileService. Files are downloaded over the configured transport,
+//using tlsConfig for HTTP downloads if it's not nil
+func NewFileService(config *Config, c cache.Cache, client rpc.FileSetClient, tlsConfig *tls.Config) *FileService {
+	var fetcher Fetcher = NewHTTPFetcher(config, tlsConfig)
+	if config.Transport == TransportGRPC {
+		fetcher = &GRPCFetcher{Client: client, MD: Metadata(config)}
+	}
+
 	f := &FileService{
-		config: config,
-		cache:  c,
-		client: client,
-		sets:   make(map[string]*file.VersionedSet),
-		mu:     new(sync.RWMutex),
-		scan:   make(chan []string, len(config.Groups)),
+		config:  config,
+		cache:   c,
+		client:  client,
+		fetcher: fetcher,
+		sets:    make(map[string]*file.VersionedSet),
+		drift:   make(map[string]uint64),
+		mu:      new(sync.RWMutex),
+		scan:    make(chan []string, len(config.Groups)),
 	}
 	go f.timer()
 	return f
@@ -61,23 +73,64 @@ func (s *FileService) Versions() map[string]uint64 {
 	return v
 }
 
+//Drift returns the number of files repaired in the last verification pass of each group
+func (s *FileService) Drift() map[string]uint64 {
+	//map[group]count
+	d := make(map[string]uint64)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for group, count := range s.drift {
+		d[group] = count
+	}
+	return d
+}
+
 func (s *FileService) timer() {
+	verify := time.NewTicker(s.config.VerifyInterval * time.Second)
+	defer verify.Stop()
+
 	groups := s.config.Groups
 	for {
 		err := s.check(groups...)
 		if err != nil {
 			log.Println("FileService: Error downloading files:", err)
 		}
+		if err = s.pruneDropped(); err != nil {
+			log.Println("FileService: Error removing files:", err)
+		}
+		next := time.After(s.config.CheckInterval * time.Second)
+	wait:
 		select {
-		case <-time.After(s.config.CheckInterval * time.Second):
+		case <-next:
 			groups = s.config.Groups
 		case groups = <-s.scan:
+		case <-verify.C:
+			if err = s.verify(); err != nil {
+				log.Println("FileService: Error verifying files:", err)
+			}
+			goto wait
 		}
 	}
 }
 
+//verify checks the installed sets for files that were changed locally, downloading them again
+func (s *FileService) verify() error {
+	sets := make(map[string]*file.VersionedSet)
+	s.mu.RLock()
+	for group, vs := range s.sets {
+		sets[group] = vs
+	}
+	s.mu.RUnlock()
+	return s.walk(sets, true)
+}
+
 func (s *FileService) check(groups ...string) error {
-	resp, err := s.client.Get(context.Background(), &rpc.FileSetRequest{Groups: groups})
+	var resp *rpc.FileSetResponse
+	err := newRetrier(s.config).do("FileSetRequest", func() error {
+		var err error
+		resp, err = s.client.Get(metadata.NewContext(context.Background(), Metadata(s.config)), &rpc.FileSetRequest{Groups: groups})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("FileSetRequest error: %v", err)
 	}
@@ -85,78 +138,247 @@ func (s *FileService) check(groups ...string) error {
 	//convert fileset
 	var grps sort.StringSlice
 	sets := make(map[string]*file.VersionedSet)
-	for group, set := range resp.Sets {
-		sets[group] = &file.VersionedSet{Set: set.Set, Version: set.Version}
-		grps = append(grps, fmt.Sprintf("{Group: %s, Len: %d, Version: %d}", group, len(set.Set), set.Version))
+	for group, vs := range resp.Sets {
+		set := make(file.Set, len(vs.Files))
+		for path, f := range vs.Files {
+			set[path] = &file.Entry{
+				Hash:   f.GetHash(),
+				Digest: f.GetDigest(),
+				Mode:   os.FileMode(f.GetMode()),
+				Owner:  f.GetOwner(),
+				Group:  f.GetGroup(),
+				Delete: file.DeletePolicy(f.GetDelete()),
+			}
+		}
+		if v := set.Version(); v != vs.Version {
+			log.Printf("FileSetResponse: Skipping Group: %s, Version mismatch: Expected %d, Result: %d\n", group, vs.Version, v)
+			continue
+		}
+		sets[group] = &file.VersionedSet{Set: set, Version: vs.Version}
+		grps = append(grps, fmt.Sprintf("{Group: %s, Len: %d, Version: %d}", group, len(set), vs.Version))
 	}
 	grps.Sort()
 	log.Printf("FileSetResponse: %s\n", strings.Join(grps, ", "))
 
 	//walk and download
-	return s.walk(sets)
+	return s.walk(sets, false)
 }
 
-func (s *FileService) walk(sets map[string]*file.VersionedSet) error {
+//walk downloads the files in sets that aren't cached or whose content has changed.
+//If verify is true, files are also checked for local changes.
+//Each group's files are staged and then committed together, so a group is either fully updated or left as it was
+func (s *FileService) walk(sets map[string]*file.VersionedSet, verify bool) error {
+	r := newRetrier(s.config)
 	for group, vs := range sets {
-		for hash, path := range vs.Set {
-			_, _, err := s.cache.Get(path)
-			if err == cache.ErrorInvalidCacheEntry {
-				err = Download(fmt.Sprintf("http://%s/file/%d", s.config.HTTPServerAddr, hash), path, hash)
-				if err != nil {
-					return fmt.Errorf("Download: Error: %v", err)
-				}
-				log.Printf("Download: Path: %s, Hash: %d\n", path, hash)
+		var drift uint64
+		var downloads []*download
+		var updates []*update
+		for path, entry := range vs.Set {
+			c, err := s.cache.Get(path)
+			if err != nil && err != cache.ErrorInvalidCacheEntry {
+				return fmt.Errorf("Cache.Get error: %v", err)
+			}
 
-				err = s.cache.Put(path, hash, time.Now())
-				if err != nil {
-					return fmt.Errorf("Cache.Put error: %v", err)
+			//download if path isn't cached or its content has changed
+			fetch := err == cache.ErrorInvalidCacheEntry || c.Hash != entry.Hash || digestChanged(c.Digest, entry.Digest)
+			if !fetch && c.Digest != entry.Digest {
+				//the server disabled digests or switched algorithms
+				if fetch, err = s.retag(path, c, entry.Digest); err != nil {
+					return fmt.Errorf("Digest: Error: %v", err)
 				}
-			} else if err != nil {
-				return fmt.Errorf("Cache.Get error: %v", err)
 			}
+			if !fetch && verify {
+				if fetch, err = s.drifted(path, c); err != nil {
+					return fmt.Errorf("Verify: Error: %v", err)
+				}
+				if fetch {
+					drift++
+					log.Printf("Verify: Drift detected: Path: %s\n", path)
+				}
+			}
+
+			if fetch {
+				downloads = append(downloads, &download{path: path, entry: entry})
+				continue
+			}
+
+			//metadata may change without content changing
+			updates = append(updates, &update{path: path, entry: entry})
+		}
+
+		tx := &transaction{group: group, fetcher: s.fetcher, updates: updates}
+		if err := tx.stage(downloads, s.config.DownloadWorkers, r); err != nil {
+			tx.rollback(s.cache)
+			return fmt.Errorf("Download: Error: %v", err)
+		}
+
+		if err := tx.commit(s.cache); err != nil {
+			return fmt.Errorf("Commit: Error: %v", err)
+		}
+
+		if err := s.prune(group, vs.Set, true); err != nil {
+			return fmt.Errorf("Prune: Error: %v", err)
 		}
 
 		//everything has been downloaded and cached so update version
 		s.mu.Lock()
 		s.sets[group] = vs
+		if verify {
+			s.drift[group] = drift
+		}
 		s.mu.Unlock()
 	}
 	return nil
 }
 
-//Download downloads url to path, verifing that the file's hash matches hash
-func Download(url, path string, hash uint64) error {
-	resp, err := http.Get(url)
+//digestAlgorithm returns the algorithm of the algorithm tagged digest
+func digestAlgorithm(digest string) string {
+	return strings.SplitN(digest, ":", 2)[0]
+}
+
+//digestChanged returns true if the cached and server digests of a file differ.
+//Digests are only compared if both exist and use the same algorithm
+func digestChanged(cached, digest string) bool {
+	return cached != "" && digest != "" && digestAlgorithm(cached) == digestAlgorithm(digest) && cached != digest
+}
+
+//retag replaces the digest of path's cache entry c with digest, returning true if path needs to be downloaded again.
+//A new digest is computed from path so the cache only holds verified digests
+func (s *FileService) retag(path string, c *cache.Entry, digest string) (bool, error) {
+	if digest != "" {
+		hasher, err := file.DigestHasher(digest)
+		if err != nil {
+			return false, fmt.Errorf("Error verifying %s: %v", path, err)
+		}
+		_, d, err := file.HashDigest(path, hasher)
+		if os.IsNotExist(err) {
+			return true, nil
+		} else if err != nil {
+			return false, fmt.Errorf("Error hashing file %s: %v", path, err)
+		}
+		if d != digest {
+			return true, nil
+		}
+	}
+
+	c.Digest = digest
+	if err := s.cache.Put(path, c); err != nil {
+		return false, fmt.Errorf("Cache.Put error: %v", err)
+	}
+	return false, nil
+}
+
+//drifted returns true if path is missing or its content no longer matches its cache entry.
+//The file is only hashed if its mtime or size has changed
+func (s *FileService) drifted(path string, c *cache.Entry) (bool, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("Error reading %s: %v", path, err)
+	}
+
+	if info.ModTime().Equal(c.ModTime) && info.Size() == c.Size {
+		return false, nil
+	}
+
+	var hasher file.Hasher
+	if c.Digest != "" {
+		if hasher, err = file.DigestHasher(c.Digest); err != nil {
+			return false, fmt.Errorf("Error verifying %s: %v", path, err)
+		}
+	}
+
+	h, d, err := file.HashDigest(path, hasher)
 	if err != nil {
-		return fmt.Errorf("Error getting %s: %v", url, err)
+		return false, fmt.Errorf("Error hashing file %s: %v", path, err)
+	}
+	if h != c.Hash || d != c.Digest {
+		return true, nil
+	}
+
+	//content is unchanged so store the new mtime and size to skip hashing next time
+	c.ModTime, c.Size = info.ModTime(), info.Size()
+	if err = s.cache.Put(path, c); err != nil {
+		return false, fmt.Errorf("Cache.Put error: %v", err)
+	}
+	return false, nil
+}
+
+//Download downloads the file with the given hash with fetcher to a temporary file in path's directory,
+//verifing that the file's hash matches hash, and returns the temporary file's path.
+//If digest is not empty, the file is also verified against the algorithm tagged digest.
+//The temporary file is synced to disk so it can be renamed over path, and is removed if an error occurs
+func Download(fetcher Fetcher, path string, hash uint64, digest string) (string, error) {
+	var hasher file.Hasher
+	if digest != "" {
+		var err error
+		if hasher, err = file.DigestHasher(digest); err != nil {
+			return "", permanent(fmt.Errorf("Error verifying %s: %v", path, err))
+		}
 	}
-	defer resp.Body.Close()
 
-	err = os.MkdirAll(filepath.Dir(path), 0777)
+	body, err := fetcher.Fetch(hash)
 	if err != nil {
-		return fmt.Errorf("Error creating directory %s: %v", filepath.Dir(path), err)
+		return "", err
 	}
+	defer body.Close()
 
-	f, err := os.Create(path)
+	err = os.MkdirAll(filepath.Dir(path), 0755)
 	if err != nil {
-		return fmt.Errorf("Error creating file %s: %v", path, err)
+		return "", fmt.Errorf("Error creating directory %s: %v", filepath.Dir(path), err)
 	}
 
-	_, err = io.Copy(f, resp.Body)
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
 	if err != nil {
+		return "", fmt.Errorf("Error creating temporary file for %s: %v", path, err)
+	}
+	tmp := f.Name()
+	success := false
+	defer func() {
+		if !success {
+			os.Remove(tmp)
+		}
+	}()
+
+	//keep the existing file's permissions until metadata is applied
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err = f.Chmod(mode); err != nil {
 		f.Close()
-		return fmt.Errorf("Error writing to file %s: %v", path, err)
+		return "", fmt.Errorf("Error setting mode of file %s: %v", tmp, err)
 	}
-	f.Close()
 
-	h, err := file.Hash(path)
+	d := file.NewDigester(hasher)
+	_, err = io.Copy(io.MultiWriter(f, d), body)
 	if err != nil {
-		return fmt.Errorf("Error hashing file %s: %v", path, err)
+		f.Close()
+		if p, ok := err.(permanentError); ok {
+			return "", p
+		}
+		return "", fmt.Errorf("Error writing to file %s: %v", tmp, err)
 	}
 
+	h, dgst := d.Sum()
 	if hash != h {
-		return fmt.Errorf("Hash mismatch on file %s: Expected %d, Result: %d", path, hash, h)
+		f.Close()
+		return "", permanent(fmt.Errorf("Hash mismatch on file %s: Expected %d, Result: %d", path, hash, h))
+	}
+	if digest != dgst {
+		f.Close()
+		return "", permanent(fmt.Errorf("Digest mismatch on file %s: Expected %s, Result: %s", path, digest, dgst))
 	}
 
-	return nil
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return "", fmt.Errorf("Error syncing file %s: %v", tmp, err)
+	}
+	if err = f.Close(); err != nil {
+		return "", fmt.Errorf("Error closing file %s: %v", tmp, err)
+	}
+
+	success = true
+	return tmp, nil
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korylprince/jettison/lib/cache"
+	"github.com/korylprince/jettison/lib/file"
+)
+
+func TestDigestChanged(t *testing.T) {
+	tests := []struct {
+		name           string
+		cached, digest string
+		changed        bool
+	}{
+		{"same", "sha256:aa", "sha256:aa", false},
+		{"different", "sha256:aa", "sha256:bb", true},
+		{"digests disabled", "sha256:aa", "", false},
+		{"digests enabled", "", "sha256:aa", false},
+		{"algorithm switched", "sha256:aa", "blake2b:bb", false},
+	}
+
+	for _, test := range tests {
+		if c := digestChanged(test.cached, test.digest); c != test.changed {
+			t.Errorf("%s: expected %v, got %v", test.name, test.changed, c)
+		}
+	}
+}
+
+func TestRetag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := cache.NewBoltCache(filepath.Join(dir, "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	path := filepath.Join(dir, "file")
+	if err = ioutil.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hasher, err := file.NewHasher("blake2b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, digest, err := file.HashDigest(path, hasher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		digest string
+		fetch  bool
+		cached string
+	}{
+		{"disabled", "", false, ""},
+		{"matching", digest, false, digest},
+		{"mismatched", "blake2b:00", true, ""},
+	}
+
+	s := &FileService{cache: c}
+	for _, test := range tests {
+		fetch, err := s.retag(path, &cache.Entry{Hash: 1, Digest: "sha256:aa"}, test.digest)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if fetch != test.fetch {
+			t.Errorf("%s: expected fetch %v, got %v", test.name, test.fetch, fetch)
+		}
+		if test.fetch {
+			continue
+		}
+		e, err := c.Get(path)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if e.Digest != test.cached {
+			t.Errorf("%s: expected cached digest %q, got %q", test.name, test.cached, e.Digest)
+		}
+	}
+}
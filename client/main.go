@@ -5,10 +5,9 @@ import (
 
 	"github.com/korylprince/jettison/lib/cache"
 	"github.com/korylprince/jettison/lib/rpc"
-	"golang.org/x/net/context"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -18,30 +17,30 @@ func main() {
 	}
 	log.Printf("Config: %#v\n", *config)
 
-	conn, err := grpc.Dial(config.RPCServerAddr, grpc.WithInsecure())
+	tlsConfig, err := TLSConfig(config)
 	if err != nil {
-		log.Fatalf("GRPC connection error: %v", err)
+		log.Fatalln("TLS config error:", err)
 	}
-	defer conn.Close()
-
-	var md metadata.MD = map[string][]string{"groups": config.Groups}
-	ctx := metadata.NewContext(context.Background(), md)
 
-	eventsClient := rpc.NewEventsClient(conn)
-	fileClient := rpc.NewFileSetClient(conn)
+	creds := grpc.WithInsecure()
+	if tlsConfig != nil {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
 
-	stream, err := eventsClient.Stream(ctx)
+	conn, err := grpc.Dial(config.RPCServerAddr, creds)
 	if err != nil {
-		log.Fatalf("stream create error: %v", err)
+		log.Fatalf("GRPC connection error: %v", err)
 	}
+	defer conn.Close()
+
+	fileClient := rpc.NewFileSetClient(conn)
 
 	c, err := cache.NewBoltCache(config.CachePath)
 	if err != nil {
 		log.Fatalf("cache create error: %v", err)
 	}
 
-	fileService := NewFileService(config, c, fileClient)
+	fileService := NewFileService(config, c, fileClient, tlsConfig)
 
-	go NotificationService(fileService, stream)
-	ReportService(config, stream, fileService)
+	EventService(config, conn, fileService)
 }
@@ -0,0 +1,102 @@
+// +build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/korylprince/jettison/lib/file"
+)
+
+//modeMask is the part of os.FileMode set by the server
+const modeMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+//fileMetadata is the mode and ownership of a file
+type fileMetadata struct {
+	mode     os.FileMode
+	uid, gid int
+}
+
+//readMetadata returns the mode and ownership of path or an error if one occurred
+func readMetadata(path string) (*fileMetadata, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading metadata of %s: %v", path, err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, fmt.Errorf("Error reading ownership of %s: unsupported platform", path)
+	}
+	return &fileMetadata{mode: info.Mode() & modeMask, uid: int(stat.Uid), gid: int(stat.Gid)}, nil
+}
+
+//restoreMetadata sets the mode and ownership of path to m, returning an error if one occurred
+func restoreMetadata(path string, m *fileMetadata) error {
+	if err := os.Lchown(path, m.uid, m.gid); err != nil {
+		return fmt.Errorf("Error setting ownership of %s: %v", path, err)
+	}
+	if err := os.Chmod(path, m.mode); err != nil {
+		return fmt.Errorf("Error setting mode of %s: %v", path, err)
+	}
+	return nil
+}
+
+//ApplyMetadata sets the mode and ownership of path to entry's if they differ,
+//returning true if anything was changed or an error if one occurred.
+//Ownership is set before mode since chown clears setuid and setgid bits
+func ApplyMetadata(path string, entry *file.Entry) (bool, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, fmt.Errorf("Error reading metadata of %s: %v", path, err)
+	}
+	changed := false
+
+	if entry.Owner != "" || entry.Group != "" {
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return false, fmt.Errorf("Error reading ownership of %s: unsupported platform", path)
+		}
+		uid, gid := int(stat.Uid), int(stat.Gid)
+
+		if entry.Owner != "" {
+			u, err := user.Lookup(entry.Owner)
+			if err != nil {
+				return false, fmt.Errorf("Error looking up user %s: %v", entry.Owner, err)
+			}
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return false, fmt.Errorf("Error parsing uid of user %s: %v", entry.Owner, err)
+			}
+		}
+
+		if entry.Group != "" {
+			g, err := user.LookupGroup(entry.Group)
+			if err != nil {
+				return false, fmt.Errorf("Error looking up group %s: %v", entry.Group, err)
+			}
+			if gid, err = strconv.Atoi(g.Gid); err != nil {
+				return false, fmt.Errorf("Error parsing gid of group %s: %v", entry.Group, err)
+			}
+		}
+
+		if uid != int(stat.Uid) || gid != int(stat.Gid) {
+			if err = os.Lchown(path, uid, gid); err != nil {
+				return false, fmt.Errorf("Error setting ownership of %s: %v", path, err)
+			}
+			changed = true
+		}
+	}
+
+	//a zero mode means the server didn't send one
+	if entry.Mode != 0 && (changed || info.Mode()&modeMask != entry.Mode) {
+		if err = os.Chmod(path, entry.Mode); err != nil {
+			return false, fmt.Errorf("Error setting mode of %s: %v", path, err)
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,23 @@
+// +build windows
+
+package main
+
+import "github.com/korylprince/jettison/lib/file"
+
+//fileMetadata is empty on Windows
+type fileMetadata struct{}
+
+//readMetadata does nothing on Windows
+func readMetadata(path string) (*fileMetadata, error) {
+	return new(fileMetadata), nil
+}
+
+//restoreMetadata does nothing on Windows
+func restoreMetadata(path string, m *fileMetadata) error {
+	return nil
+}
+
+//ApplyMetadata does nothing on Windows since Unix modes and ownership don't apply
+func ApplyMetadata(path string, entry *file.Entry) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/korylprince/jettison/lib/file"
+)
+
+//deleteLimitThreshold is the number of files that can be removed from a group at once regardless of MaxDeletePercent,
+//so small groups can still lose files
+const deleteLimitThreshold = 5
+
+//prune removes the files in group's manifest that are no longer in set, following each file's delete policy,
+//then stores set as group's manifest. If limit is true, nothing is removed when more than deleteLimitThreshold files
+//and more than MaxDeletePercent of the manifest would be. Files that fail to be removed are kept in the manifest to be retried
+func (s *FileService) prune(group string, set file.Set, limit bool) error {
+	manifest, err := s.cache.GetManifest(group)
+	if err != nil {
+		return fmt.Errorf("Error getting manifest for group %s: %v", group, err)
+	}
+
+	//paths installed by other groups must not be removed
+	inUse := make(map[string]struct{})
+	groups, err := s.cache.ManifestGroups()
+	if err != nil {
+		return fmt.Errorf("Error getting manifest groups: %v", err)
+	}
+	for _, g := range groups {
+		if g == group {
+			continue
+		}
+		m, err := s.cache.GetManifest(g)
+		if err != nil {
+			return fmt.Errorf("Error getting manifest for group %s: %v", g, err)
+		}
+		for path := range m {
+			inUse[path] = struct{}{}
+		}
+	}
+
+	removed := make(file.Set)
+	for path, entry := range manifest {
+		if _, ok := set[path]; ok {
+			continue
+		}
+		removed[path] = entry
+	}
+
+	next := make(file.Set, len(set))
+	for path, entry := range set {
+		next[path] = entry
+	}
+
+	if limit && len(removed) > deleteLimitThreshold && len(removed)*100 > s.config.MaxDeletePercent*len(manifest) {
+		log.Printf("Prune: Refusing to remove %d of %d files in group %s: more than %d%%\n",
+			len(removed), len(manifest), group, s.config.MaxDeletePercent)
+		//keep tracking the files so they can be removed later
+		for path, entry := range removed {
+			next[path] = entry
+		}
+		removed = nil
+	}
+
+	stamp := time.Now().Format("20060102150405")
+	for path, entry := range removed {
+		if _, ok := inUse[path]; ok {
+			continue
+		}
+		if err = s.remove(group, stamp, path, entry); err != nil {
+			log.Println("Prune: Error:", err)
+			next[path] = entry
+			continue
+		}
+		log.Printf("Prune: Group: %s, Path: %s, Policy: %s\n", group, path, entry.Delete)
+	}
+
+	if len(next) == 0 {
+		err = s.cache.DeleteManifest(group)
+	} else {
+		err = s.cache.PutManifest(group, next)
+	}
+	if err != nil {
+		return fmt.Errorf("Error storing manifest for group %s: %v", group, err)
+	}
+	return nil
+}
+
+//remove removes path according to entry's delete policy.
+//Quarantined files are moved to QuarantinePath/<group>/<stamp>/<path>
+func (s *FileService) remove(group, stamp, path string, entry *file.Entry) error {
+	switch entry.Delete {
+	case file.DeleteKeep:
+	case file.DeleteQuarantine:
+		if s.config.QuarantinePath == "" {
+			return fmt.Errorf("Error quarantining %s: JETTISON_QUARANTINEPATH must be configured", path)
+		}
+		dest := filepath.Join(s.config.QuarantinePath, group, stamp, strings.TrimPrefix(path, filepath.VolumeName(path)))
+		if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+			return fmt.Errorf("Error creating directory %s: %v", filepath.Dir(dest), err)
+		}
+		if err := os.Rename(path, dest); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Error quarantining %s: %v", path, err)
+		}
+	default:
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Error removing %s: %v", path, err)
+		}
+	}
+
+	//the file will be downloaded again if it's added back
+	if err := s.cache.Delete(path); err != nil {
+		return fmt.Errorf("Cache.Delete error: %v", err)
+	}
+	return nil
+}
+
+//pruneDropped removes the files of groups that are no longer configured.
+//The MaxDeletePercent limit applies unless UnlimitedDropRemoval is set, so a mistyped group can't remove every file of a group
+func (s *FileService) pruneDropped() error {
+	groups, err := s.cache.ManifestGroups()
+	if err != nil {
+		return fmt.Errorf("Error getting manifest groups: %v", err)
+	}
+
+	configured := make(map[string]struct{})
+	for _, group := range s.config.Groups {
+		configured[group] = struct{}{}
+	}
+
+	for _, group := range groups {
+		if _, ok := configured[group]; ok {
+			continue
+		}
+		log.Printf("Prune: Removing files of dropped group %s\n", group)
+		if err = s.prune(group, nil, !s.config.UnlimitedDropRemoval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korylprince/jettison/lib/cache"
+	"github.com/korylprince/jettison/lib/file"
+)
+
+func TestPruneLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   int
+		removed int
+		limit   bool
+		allowed bool
+	}{
+		{"1 of 1", 1, 1, true, true},
+		{"1 of 2", 2, 1, true, true},
+		{"threshold of threshold", deleteLimitThreshold, deleteLimitThreshold, true, true},
+		{"under percent", 20, 6, true, true},
+		{"at percent", 20, 10, true, true},
+		{"over percent", 20, 11, true, false},
+		{"all over threshold", deleteLimitThreshold + 1, deleteLimitThreshold + 1, true, false},
+		{"unlimited", 20, 20, false, true},
+	}
+
+	for _, test := range tests {
+		dir, err := ioutil.TempDir("", "jettison")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		c, err := cache.NewBoltCache(filepath.Join(dir, "cache.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		manifest, set := make(file.Set), make(file.Set)
+		for i := 0; i < test.files; i++ {
+			path := filepath.Join(dir, fmt.Sprintf("file%d", i))
+			if err = ioutil.WriteFile(path, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			manifest[path] = &file.Entry{Hash: uint64(i)}
+			if i >= test.removed {
+				set[path] = manifest[path]
+			}
+		}
+		if err = c.PutManifest("test", manifest); err != nil {
+			t.Fatal(err)
+		}
+
+		s := &FileService{config: &Config{MaxDeletePercent: 50}, cache: c}
+		if err = s.prune("test", set, test.limit); err != nil {
+			t.Fatalf("%s: prune: %v", test.name, err)
+		}
+
+		next, err := c.GetManifest("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, statErr := os.Stat(filepath.Join(dir, "file0"))
+		if test.allowed {
+			if !os.IsNotExist(statErr) {
+				t.Errorf("%s: expected file to be removed", test.name)
+			}
+			if len(next) != len(set) {
+				t.Errorf("%s: expected manifest of %d files, got %d", test.name, len(set), len(next))
+			}
+		} else {
+			if statErr != nil {
+				t.Errorf("%s: expected file to be kept: %v", test.name, statErr)
+			}
+			if len(next) != len(manifest) {
+				t.Errorf("%s: expected manifest of %d files, got %d", test.name, len(manifest), len(next))
+			}
+		}
+	}
+}
+
+func TestPruneDropped(t *testing.T) {
+	for _, unlimited := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "jettison")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		c, err := cache.NewBoltCache(filepath.Join(dir, "cache.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		manifest := make(file.Set)
+		for i := 0; i <= deleteLimitThreshold; i++ {
+			path := filepath.Join(dir, fmt.Sprintf("file%d", i))
+			if err = ioutil.WriteFile(path, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			manifest[path] = &file.Entry{Hash: uint64(i)}
+		}
+		if err = c.PutManifest("dropped", manifest); err != nil {
+			t.Fatal(err)
+		}
+
+		s := &FileService{config: &Config{Groups: []string{"base"}, MaxDeletePercent: 50, UnlimitedDropRemoval: unlimited}, cache: c}
+		if err = s.pruneDropped(); err != nil {
+			t.Fatalf("unlimited %v: pruneDropped: %v", unlimited, err)
+		}
+
+		_, err = os.Stat(filepath.Join(dir, "file0"))
+		if removed := os.IsNotExist(err); removed != unlimited {
+			t.Errorf("unlimited %v: expected removed %v, got %v", unlimited, unlimited, removed)
+		}
+	}
+}
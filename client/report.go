@@ -2,10 +2,10 @@ package main
 
 import (
 	"log"
-	"os"
 	"time"
 
 	"github.com/korylprince/jettison/lib/rpc"
+	"golang.org/x/net/context"
 )
 
 //GenerateReport generates an *rpc.Report from the given Config and FileService
@@ -14,23 +14,27 @@ func GenerateReport(config *Config, fs *FileService) *rpc.Report {
 		HardwareAddr: config.HardwareAddr,
 		Location:     config.Location,
 		Version:      fs.Versions(),
+		Drift:        fs.Drift(),
 	}
 }
 
-//ReportService is a GRPC ReportService
-func ReportService(config *Config, stream rpc.Events_StreamClient, fs *FileService) {
+//ReportService is a GRPC ReportService. It returns when the stream fails or ctx is cancelled
+func ReportService(ctx context.Context, config *Config, stream rpc.Events_StreamClient, fs *FileService) error {
 	log.Println("Report: Service Started")
 	for {
 		rpt := GenerateReport(config, fs)
-		log.Printf("Report: HardwareAddr: %s, Location: %s, Version: %v",
-			rpt.GetHardwareAddr(), rpt.GetLocation(), rpt.GetVersion())
+		log.Printf("Report: HardwareAddr: %s, Location: %s, Version: %v, Drift: %v",
+			rpt.GetHardwareAddr(), rpt.GetLocation(), rpt.GetVersion(), rpt.GetDrift())
 
 		err := stream.Send(rpt)
 		if err != nil {
-			log.Printf("Report: EXITING, Error: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 
-		time.Sleep(config.ReportInterval * time.Second)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(config.ReportInterval * time.Second):
+		}
 	}
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//permanentError is an error that won't be resolved by retrying
+type permanentError struct {
+	error
+}
+
+//permanent marks err as permanent so it isn't retried
+func permanent(err error) error {
+	return permanentError{err}
+}
+
+//backoff returns a random wait between half and all of delay, and the next delay, doubled up to max
+func backoff(delay, max time.Duration) (wait, next time.Duration) {
+	wait = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	if next = delay * 2; next > max {
+		next = max
+	}
+	return wait, next
+}
+
+//retrier retries operations with jittered exponential backoff.
+//All operations share a budget of retries, so a failing server isn't retried indefinitely
+type retrier struct {
+	config *Config
+	budget int //retries left
+	mu     *sync.Mutex
+}
+
+//newRetrier returns a new retrier with the configured retry budget
+func newRetrier(config *Config) *retrier {
+	return &retrier{config: config, budget: config.RetryBudget, mu: new(sync.Mutex)}
+}
+
+//take returns true if a retry is left in the budget, using it
+func (r *retrier) take() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.budget <= 0 {
+		return false
+	}
+	r.budget--
+	return true
+}
+
+//do calls f until it succeeds, it returns a permanent error, RetryAttempts attempts have been made, or the budget is spent.
+//Between attempts do waits between half and all of a delay that doubles from RetryDelay up to RetryMaxDelay
+func (r *retrier) do(name string, f func() error) error {
+	delay := r.config.RetryDelay * time.Second
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		if p, ok := err.(permanentError); ok {
+			return p.error
+		}
+		if attempt >= r.config.RetryAttempts || !r.take() {
+			return err
+		}
+
+		var wait time.Duration
+		wait, delay = backoff(delay, r.config.RetryMaxDelay*time.Second)
+		log.Printf("Retry: %s: Attempt %d failed, retrying in %v: %v\n", name, attempt, wait, err)
+		time.Sleep(wait)
+	}
+}
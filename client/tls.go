@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+//TLSConfig returns the *tls.Config used for RPCs and downloads, or nil if TLS isn't configured.
+//If TLSCA is configured, only servers with certificates signed by it are trusted.
+//If TLSCert is configured, it's presented to the server
+func TLSConfig(config *Config) (*tls.Config, error) {
+	if !config.TLS {
+		return nil, nil
+	}
+
+	c := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.TLSCA != "" {
+		buf, err := ioutil.ReadFile(config.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(buf) {
+			return nil, fmt.Errorf("Error reading CA: no certificates found in %s", config.TLSCA)
+		}
+		c.RootCAs = pool
+	}
+
+	if config.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading certificate: %v", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+
+	return c, nil
+}
@@ -2,19 +2,35 @@ package cache
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/boltdb/bolt"
+	"github.com/korylprince/jettison/lib/file"
 )
 
 //ErrorInvalidCacheEntry signals that the given path has an invalid or empty cache entry
 var ErrorInvalidCacheEntry = fmt.Errorf("invalid cache entry")
 
-//Cache is an interface for storing file metadata
+//Entry is the metadata stored for a file
+type Entry struct {
+	Hash    uint64
+	Digest  string //algorithm tagged digest (e.g. sha256:<hex>) or empty if none was computed
+	ModTime time.Time
+	Size    int64 //-1 if unknown
+}
+
+//Cache is an interface for storing file metadata.
+//Manifests are the file.Sets a client has installed for each group
 type Cache interface {
-	Get(path string) (hash uint64, mtime time.Time, err error)
-	Put(path string, hash uint64, mtime time.Time) error
+	Get(path string) (*Entry, error)
+	Put(path string, e *Entry) error
+	Delete(path string) error
+	GetManifest(group string) (file.Set, error)
+	PutManifest(group string, s file.Set) error
+	DeleteManifest(group string) error
+	ManifestGroups() ([]string, error)
 	Close() error
 }
 
@@ -30,8 +46,12 @@ func NewBoltCache(path string) (Cache, error) {
 		return nil, err
 	}
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, txErr := tx.CreateBucketIfNotExists([]byte("files"))
-		return txErr
+		for _, bucket := range []string{"files", "manifests"} {
+			if _, txErr := tx.CreateBucketIfNotExists([]byte(bucket)); txErr != nil {
+				return txErr
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
@@ -39,45 +59,129 @@ func NewBoltCache(path string) (Cache, error) {
 	return Cache(&BoltCache{db: db}), nil
 }
 
-//Get returns the hash and mtime for the given path, or an error if one occurred
-func (c *BoltCache) Get(path string) (hash uint64, mtime time.Time, err error) {
-	err = c.db.View(func(tx *bolt.Tx) error {
+//Get returns the Entry for the given path, or an error if one occurred
+func (c *BoltCache) Get(path string) (*Entry, error) {
+	e := new(Entry)
+	err := c.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte("files"))
 		if b == nil {
 			return fmt.Errorf("invalid bucket: files")
 		}
 		v := b.Get([]byte(path))
-		if v == nil || len(v) != 23 {
+
+		//entries are a zero byte + size (8 bytes) + mtime (8 bytes, unix nanoseconds) + hash (8 bytes) + digest (optional)
+		if len(v) >= 25 && v[0] == 0 {
+			e.Size = int64(binary.BigEndian.Uint64(v[1:9]))
+			e.ModTime = time.Unix(0, int64(binary.BigEndian.Uint64(v[9:17])))
+			e.Hash = binary.BigEndian.Uint64(v[17:25])
+			e.Digest = string(v[25:])
+			return nil
+		}
+
+		//older entries are mtime (15 bytes) + hash (8 bytes) + digest (optional).
+		//binary encoded time.Time never starts with a zero byte
+		if len(v) < 23 {
 			return ErrorInvalidCacheEntry
 		}
-		err = mtime.UnmarshalBinary(v[0:15]) //length of binary encoded time.Time
-		if err != nil {
+		if err := e.ModTime.UnmarshalBinary(v[0:15]); err != nil { //length of binary encoded time.Time
 			return err
 		}
-		hash = binary.BigEndian.Uint64(v[15:23])
+		e.Size = -1
+		e.Hash = binary.BigEndian.Uint64(v[15:23])
+		e.Digest = string(v[23:])
 		return nil
 	})
-	return hash, mtime, err
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
 }
 
-//Put sets the hash and mtime for the given path or will return an error if one occurred
-func (c *BoltCache) Put(path string, hash uint64, mtime time.Time) error {
-	err := c.db.Update(func(tx *bolt.Tx) error {
-		t, err := mtime.MarshalBinary()
-		if err != nil {
-			return err
-		}
+//Put sets the Entry for the given path or will return an error if one occurred
+func (c *BoltCache) Put(path string, e *Entry) error {
+	v := make([]byte, 25, 25+len(e.Digest))
+	binary.BigEndian.PutUint64(v[1:9], uint64(e.Size))
+	binary.BigEndian.PutUint64(v[9:17], uint64(e.ModTime.UnixNano()))
+	binary.BigEndian.PutUint64(v[17:25], e.Hash)
+	v = append(v, e.Digest...)
 
-		h := make([]byte, 8)
-		binary.BigEndian.PutUint64(h, hash)
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("files"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: files")
+		}
+		return b.Put([]byte(path), v)
+	})
+}
 
+//Delete removes the entry for the given path or will return an error if one occurred
+func (c *BoltCache) Delete(path string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte("files"))
 		if b == nil {
 			return fmt.Errorf("invalid bucket: files")
 		}
-		return b.Put([]byte(path), append(t, h...))
+		return b.Delete([]byte(path))
+	})
+}
+
+//GetManifest returns the manifest for the given group, or nil if none exists, or an error if one occurred
+func (c *BoltCache) GetManifest(group string) (file.Set, error) {
+	var s file.Set
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("manifests"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: manifests")
+		}
+		v := b.Get([]byte(group))
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(v, &s)
+	})
+	return s, err
+}
+
+//PutManifest sets the manifest for the given group or will return an error if one occurred
+func (c *BoltCache) PutManifest(group string, s file.Set) error {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("manifests"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: manifests")
+		}
+		return b.Put([]byte(group), buf)
+	})
+}
+
+//DeleteManifest removes the manifest for the given group or will return an error if one occurred
+func (c *BoltCache) DeleteManifest(group string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("manifests"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: manifests")
+		}
+		return b.Delete([]byte(group))
+	})
+}
+
+//ManifestGroups returns the groups that have manifests or an error if one occurred
+func (c *BoltCache) ManifestGroups() ([]string, error) {
+	var groups []string
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("manifests"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: manifests")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			groups = append(groups, string(k))
+			return nil
+		})
 	})
-	return err
+	return groups, err
 }
 
 //Close closes the underlying boltdb database
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+//BoltStore is an implementation of Store on top of boltdb
+type BoltStore struct {
+	db *bolt.DB
+}
+
+//NewBoltStore returns a new Store
+func NewBoltStore(path string) (Store, error) {
+	//bolt locks the file, so give up if another process already has it open
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, txErr := tx.CreateBucketIfNotExists([]byte("latest")); txErr != nil {
+			return txErr
+		}
+		_, txErr := tx.CreateBucketIfNotExists([]byte("history"))
+		return txErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Store(&BoltStore{db: db}), nil
+}
+
+//Put saves rpt as the latest report for rpt.HardwareAddr and appends it to its history
+func (s *BoltStore) Put(rpt *Report) error {
+	buf, err := json.Marshal(rpt)
+	if err != nil {
+		return err
+	}
+
+	//history keys are big endian nanoseconds so they iterate in time order
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(rpt.Time.UnixNano()))
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("latest"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: latest")
+		}
+		if err := b.Put([]byte(rpt.HardwareAddr), buf); err != nil {
+			return err
+		}
+
+		b = tx.Bucket([]byte("history"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: history")
+		}
+		h, err := b.CreateBucketIfNotExists([]byte(rpt.HardwareAddr))
+		if err != nil {
+			return err
+		}
+		return h.Put(key, buf)
+	})
+}
+
+//Latest returns the latest report for the given hardware address
+func (s *BoltStore) Latest(hardwareAddr string) (*Report, error) {
+	rpt := new(Report)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("latest"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: latest")
+		}
+		v := b.Get([]byte(hardwareAddr))
+		if v == nil {
+			return ErrorNotFound
+		}
+		return json.Unmarshal(v, rpt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rpt, nil
+}
+
+//History returns all reports for the given hardware address, oldest first
+func (s *BoltStore) History(hardwareAddr string) ([]*Report, error) {
+	var rpts []*Report
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("history"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: history")
+		}
+		h := b.Bucket([]byte(hardwareAddr))
+		if h == nil {
+			return ErrorNotFound
+		}
+		return h.ForEach(func(k, v []byte) error {
+			rpt := new(Report)
+			if err := json.Unmarshal(v, rpt); err != nil {
+				return err
+			}
+			rpts = append(rpts, rpt)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rpts, nil
+}
+
+//All returns the latest report for every hardware address
+func (s *BoltStore) All() ([]*Report, error) {
+	var rpts []*Report
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("latest"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: latest")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			rpt := new(Report)
+			if err := json.Unmarshal(v, rpt); err != nil {
+				return err
+			}
+			rpts = append(rpts, rpt)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rpts, nil
+}
+
+//Delete removes all reports for the given hardware address
+func (s *BoltStore) Delete(hardwareAddr string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("latest"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: latest")
+		}
+		if b.Get([]byte(hardwareAddr)) == nil {
+			return ErrorNotFound
+		}
+		if err := b.Delete([]byte(hardwareAddr)); err != nil {
+			return err
+		}
+
+		b = tx.Bucket([]byte("history"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: history")
+		}
+		if b.Bucket([]byte(hardwareAddr)) == nil {
+			return nil
+		}
+		return b.DeleteBucket([]byte(hardwareAddr))
+	})
+}
+
+//Close closes the underlying boltdb database
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
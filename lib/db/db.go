@@ -1,12 +1,33 @@
 package db
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+//ErrorNotFound signals that no reports exist for the given hardware address
+var ErrorNotFound = fmt.Errorf("report not found")
 
 //Report represents a client report
 type Report struct {
-	id             uint32
-	MacAddress     string
-	Location       string
-	FileSetVersion uint32
-	Time           time.Time
+	HardwareAddr string
+	Location     string
+	Version      map[string]uint64 //group:version
+	Drift        map[string]uint64 `json:",omitempty"` //group:files repaired in the client's last verification pass
+	Time         time.Time
+}
+
+//Store is an interface for storing client reports
+type Store interface {
+	//Put saves rpt as the latest report for rpt.HardwareAddr and appends it to its history
+	Put(rpt *Report) error
+	//Latest returns the latest report for the given hardware address
+	Latest(hardwareAddr string) (*Report, error)
+	//History returns all reports for the given hardware address, oldest first
+	History(hardwareAddr string) ([]*Report, error)
+	//All returns the latest report for every hardware address
+	All() ([]*Report, error)
+	//Delete removes all reports for the given hardware address
+	Delete(hardwareAddr string) error
+	Close() error
 }
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+//testStore runs the same checks against any Store
+func testStore(t *testing.T, s Store) {
+	now := time.Now().UTC().Truncate(time.Second)
+	rpts := []*Report{
+		{HardwareAddr: "aa", Location: "lab", Version: map[string]uint64{"base": 1}, Time: now},
+		{HardwareAddr: "bb", Location: "office", Version: map[string]uint64{"base": 1}, Time: now.Add(time.Second)},
+		{HardwareAddr: "aa", Location: "lab", Version: map[string]uint64{"base": 2}, Drift: map[string]uint64{"base": 3}, Time: now.Add(2 * time.Second)},
+	}
+	for _, rpt := range rpts {
+		if err := s.Put(rpt); err != nil {
+			t.Fatal("Put:", err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		hardwareAddr string
+		latest       *Report
+		history      []*Report
+		err          error
+	}{
+		{"multiple reports", "aa", rpts[2], []*Report{rpts[0], rpts[2]}, nil},
+		{"single report", "bb", rpts[1], []*Report{rpts[1]}, nil},
+		{"unknown", "cc", nil, nil, ErrorNotFound},
+	}
+
+	for _, test := range tests {
+		latest, err := s.Latest(test.hardwareAddr)
+		if err != test.err {
+			t.Errorf("%s: Latest: expected error %v, got %v", test.name, test.err, err)
+		} else if !reflect.DeepEqual(latest, test.latest) {
+			t.Errorf("%s: Latest: expected %v, got %v", test.name, test.latest, latest)
+		}
+
+		history, err := s.History(test.hardwareAddr)
+		if err != test.err {
+			t.Errorf("%s: History: expected error %v, got %v", test.name, test.err, err)
+		} else if !reflect.DeepEqual(history, test.history) {
+			t.Errorf("%s: History: expected %v, got %v", test.name, test.history, history)
+		}
+	}
+
+	all, err := s.All()
+	if err != nil {
+		t.Fatal("All:", err)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].HardwareAddr < all[j].HardwareAddr })
+	if expected := []*Report{rpts[2], rpts[1]}; !reflect.DeepEqual(all, expected) {
+		t.Errorf("All: expected %v, got %v", expected, all)
+	}
+
+	if err = s.Delete("aa"); err != nil {
+		t.Fatal("Delete:", err)
+	}
+	if err = s.Delete("aa"); err != ErrorNotFound {
+		t.Errorf("Delete again: expected error %v, got %v", ErrorNotFound, err)
+	}
+	if _, err = s.Latest("aa"); err != ErrorNotFound {
+		t.Errorf("Latest after Delete: expected error %v, got %v", ErrorNotFound, err)
+	}
+	if _, err = s.History("aa"); err != ErrorNotFound {
+		t.Errorf("History after Delete: expected error %v, got %v", ErrorNotFound, err)
+	}
+	if all, err = s.All(); err != nil || len(all) != 1 {
+		t.Errorf("All after Delete: expected 1 report, got %d (%v)", len(all), err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	defer s.Close()
+	testStore(t, s)
+}
+
+func TestBoltStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison-db-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewBoltStore(filepath.Join(dir, "reports.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	testStore(t, s)
+}
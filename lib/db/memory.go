@@ -0,0 +1,82 @@
+package db
+
+import "sync"
+
+//MemoryStore is an in-memory implementation of Store. Reports are lost when the process exits
+type MemoryStore struct {
+	history map[string][]*Report //hardwareAddr:reports
+	mu      *sync.RWMutex
+}
+
+//NewMemoryStore returns a new Store
+func NewMemoryStore() Store {
+	return Store(&MemoryStore{history: make(map[string][]*Report), mu: new(sync.RWMutex)})
+}
+
+//Put saves rpt as the latest report for rpt.HardwareAddr and appends it to its history
+func (s *MemoryStore) Put(rpt *Report) error {
+	r := *rpt
+	s.mu.Lock()
+	s.history[r.HardwareAddr] = append(s.history[r.HardwareAddr], &r)
+	s.mu.Unlock()
+	return nil
+}
+
+//Latest returns the latest report for the given hardware address
+func (s *MemoryStore) Latest(hardwareAddr string) (*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rpts, ok := s.history[hardwareAddr]
+	if !ok || len(rpts) == 0 {
+		return nil, ErrorNotFound
+	}
+	r := *rpts[len(rpts)-1]
+	return &r, nil
+}
+
+//History returns all reports for the given hardware address, oldest first
+func (s *MemoryStore) History(hardwareAddr string) ([]*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rpts, ok := s.history[hardwareAddr]
+	if !ok || len(rpts) == 0 {
+		return nil, ErrorNotFound
+	}
+	history := make([]*Report, len(rpts))
+	for i, rpt := range rpts {
+		r := *rpt
+		history[i] = &r
+	}
+	return history, nil
+}
+
+//All returns the latest report for every hardware address
+func (s *MemoryStore) All() ([]*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var all []*Report
+	for _, rpts := range s.history {
+		if len(rpts) == 0 {
+			continue
+		}
+		r := *rpts[len(rpts)-1]
+		all = append(all, &r)
+	}
+	return all, nil
+}
+
+//Delete removes all reports for the given hardware address
+func (s *MemoryStore) Delete(hardwareAddr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.history[hardwareAddr]; !ok {
+		return ErrorNotFound
+	}
+	delete(s.history, hardwareAddr)
+	return nil
+}
+
+//Close satisfies Store
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,115 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+//DefinitionVersion is the current version of the definition schema
+const DefinitionVersion = 2
+
+//SymlinkPolicy is how symlinks under an origin are handled
+type SymlinkPolicy string
+
+//SymlinkPolicies
+const (
+	SymlinkSkip   SymlinkPolicy = "skip"   //symlinks are not delivered (default)
+	SymlinkFollow SymlinkPolicy = "follow" //symlinks to files are delivered as the file they point to. Symlinked directories are not walked
+)
+
+//DeletePolicy is how a client handles a file that is removed from a group
+type DeletePolicy string
+
+//DeletePolicies
+const (
+	DeleteRemove     DeletePolicy = "delete"     //the file is deleted (default)
+	DeleteKeep       DeletePolicy = "keep"       //the file is left in place
+	DeleteQuarantine DeletePolicy = "quarantine" //the file is moved to the client's quarantine directory
+)
+
+//Definition is a go representation of a json config:
+//{"version": 2, "groups": map[group]map[origin_path]Mapping}
+//A legacy definition, map[group]map[origin_path]destination_path, is upgraded by Parse
+type Definition struct {
+	Version int                            `json:"version"`
+	Groups  map[string]map[string]*Mapping `json:"groups"`
+}
+
+//Mapping describes how an origin path is delivered.
+//Dest and the origin path must both be files or both be directories.
+//In JSON, a Mapping is either an object or just the destination path
+type Mapping struct {
+	Dest     string        `json:"dest"`
+	Mode     string        `json:"mode,omitempty"`    //octal file mode, e.g. "0644". If empty, the origin's mode is used
+	Owner    string        `json:"owner,omitempty"`   //user name. If empty, the owner isn't changed
+	Group    string        `json:"group,omitempty"`   //group name. If empty, the group isn't changed
+	Include  Patterns      `json:"include,omitempty"` //if not empty, only matching files are delivered
+	Exclude  Patterns      `json:"exclude,omitempty"` //matching files and directories are skipped
+	Symlinks SymlinkPolicy `json:"symlinks,omitempty"`
+	Delete   DeletePolicy  `json:"delete,omitempty"`
+}
+
+//UnmarshalJSON unmarshals a Mapping from a JSON object or destination path string
+func (m *Mapping) UnmarshalJSON(b []byte) error {
+	var dest string
+	if err := json.Unmarshal(b, &dest); err == nil {
+		*m = Mapping{Dest: dest}
+		return nil
+	}
+
+	type mapping Mapping //prevent recursion
+	return json.Unmarshal(b, (*mapping)(m))
+}
+
+//FileMode returns the parsed Mode, and ok if a mode was given
+func (m *Mapping) FileMode() (mode os.FileMode, ok bool, err error) {
+	if m.Mode == "" {
+		return 0, false, nil
+	}
+	unix, err := strconv.ParseUint(m.Mode, 8, 32)
+	if err != nil || unix > 07777 {
+		return 0, false, fmt.Errorf("invalid mode: %s", m.Mode)
+	}
+
+	mode = os.FileMode(unix) & os.ModePerm
+	if unix&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if unix&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if unix&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, true, nil
+}
+
+//validate checks m for invalid options and sets defaults
+func (m *Mapping) validate() error {
+	if m.Dest == "" {
+		return fmt.Errorf("no destination given")
+	}
+	if _, _, err := m.FileMode(); err != nil {
+		return err
+	}
+
+	switch m.Symlinks {
+	case "":
+		m.Symlinks = SymlinkSkip
+	case SymlinkSkip, SymlinkFollow:
+	default:
+		return fmt.Errorf("invalid symlink policy: %s", m.Symlinks)
+	}
+
+	switch m.Delete {
+	case "":
+		m.Delete = DeleteRemove
+	case DeleteRemove, DeleteKeep, DeleteQuarantine:
+	default:
+		return fmt.Errorf("invalid delete policy: %s", m.Delete)
+	}
+
+	return nil
+}
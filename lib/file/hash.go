@@ -1,23 +1,116 @@
 package file
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/OneOfOne/xxhash"
+	"golang.org/x/crypto/blake2b"
 )
 
+//Hasher is a cryptographic hash algorithm used to verify file content
+type Hasher interface {
+	//Name returns the name the algorithm's digests are tagged with
+	Name() string
+	New() hash.Hash
+}
+
+type sha256Hasher struct{}
+
+func (sha256Hasher) Name() string   { return "sha256" }
+func (sha256Hasher) New() hash.Hash { return sha256.New() }
+
+type blake2bHasher struct{}
+
+func (blake2bHasher) Name() string { return "blake2b" }
+func (blake2bHasher) New() hash.Hash {
+	h, _ := blake2b.New256(nil) //only errors with an invalid key
+	return h
+}
+
+//Hashers are the available Hashers, by name
+var Hashers = map[string]Hasher{
+	"sha256":  sha256Hasher{},
+	"blake2b": blake2bHasher{},
+}
+
+//NewHasher returns the Hasher with the given name, or an error if it doesn't exist
+func NewHasher(name string) (Hasher, error) {
+	if h, ok := Hashers[name]; ok {
+		return h, nil
+	}
+	return nil, fmt.Errorf("unknown hash algorithm: %s", name)
+}
+
+//DigestHasher returns the Hasher used to create the given algorithm tagged digest, or an error if it doesn't exist
+func DigestHasher(digest string) (Hasher, error) {
+	i := strings.Index(digest, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid digest: %s", digest)
+	}
+	return NewHasher(digest[:i])
+}
+
+//Digester is an io.Writer that computes the xxHash64 and, if it has a Hasher,
+//the algorithm tagged digest of everything written to it
+type Digester struct {
+	h  Hasher
+	xx hash.Hash64
+	d  hash.Hash
+}
+
+//NewDigester returns a new Digester. h may be nil if no digest should be computed
+func NewDigester(h Hasher) *Digester {
+	d := &Digester{h: h, xx: xxhash.New64()}
+	if h != nil {
+		d.d = h.New()
+	}
+	return d
+}
+
+//Write satisfies io.Writer
+func (d *Digester) Write(p []byte) (int, error) {
+	d.xx.Write(p)
+	if d.d != nil {
+		d.d.Write(p)
+	}
+	return len(p), nil
+}
+
+//Sum returns the xxHash64 and algorithm tagged digest (e.g. sha256:<hex>), or an empty digest if there is no Hasher
+func (d *Digester) Sum() (sum uint64, digest string) {
+	if d.d != nil {
+		digest = d.h.Name() + ":" + hex.EncodeToString(d.d.Sum(nil))
+	}
+	return d.xx.Sum64(), digest
+}
+
 //Hash returns the xxHash64 of the given path, or an error if one occurred
 func Hash(path string) (uint64, error) {
-	h := xxhash.New64()
+	h, _, err := HashDigest(path, nil)
+	return h, err
+}
+
+//HashDigest returns the xxHash64 and, if h is not nil, the algorithm tagged digest
+//(e.g. sha256:<hex>) of the given path, or an error if one occurred
+func HashDigest(path string, h Hasher) (sum uint64, digest string, err error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return 0, err
+		return 0, "", err
 	}
 	defer f.Close()
-	_, err = io.Copy(h, f)
+
+	d := NewDigester(h)
+	_, err = io.Copy(d, f)
 	if err != nil {
-		return 0, err
+		return 0, "", err
 	}
-	return h.Sum64(), nil
+
+	sum, digest = d.Sum()
+	return sum, digest, nil
 }
@@ -0,0 +1,83 @@
+package file
+
+import (
+	"path"
+	"strings"
+)
+
+//Patterns is a list of gitignore-style patterns:
+//blank lines and lines starting with # are ignored;
+//a leading ! negates a pattern, with later patterns taking precedence;
+//a trailing / only matches directories;
+//a pattern containing a / (other than a trailing one) is matched from the root, otherwise it matches at any depth;
+//* and ? match within a path segment, and ** matches any number of segments
+type Patterns []string
+
+//Match returns true if the slash separated path, relative to the root, matches p.
+//isDir should be true if the path is a directory
+func (p Patterns) Match(rel string, isDir bool) bool {
+	matched := false
+	for _, pattern := range p {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+
+		if strings.Contains(pattern, "/") {
+			pattern = strings.TrimPrefix(pattern, "/")
+		} else {
+			pattern = "**/" + pattern
+		}
+
+		if matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			matched = !negate
+		}
+	}
+	return matched
+}
+
+//MatchParents returns true if the slash separated path, relative to the root, or any of its parent directories matches p
+func (p Patterns) MatchParents(rel string, isDir bool) bool {
+	if p.Match(rel, isDir) {
+		return true
+	}
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if p.Match(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+//matchSegments returns true if the path segments match the pattern segments
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			//** matches zero or more segments
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
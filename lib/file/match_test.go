@@ -0,0 +1,60 @@
+package file
+
+import "testing"
+
+func TestPatternsMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns Patterns
+		rel      string
+		isDir    bool
+		match    bool
+	}{
+		{"empty", nil, "a", false, false},
+		{"comment and blank", Patterns{"# a", "", "  "}, "a", false, false},
+		{"name at root", Patterns{"*.log"}, "a.log", false, true},
+		{"name at any depth", Patterns{"*.log"}, "x/y/a.log", false, true},
+		{"name no match", Patterns{"*.log"}, "a.txt", false, false},
+		{"question mark", Patterns{"a?c"}, "abc", false, true},
+		{"star within segment", Patterns{"a*"}, "x/ab/c", false, false},
+		{"anchored", Patterns{"/tmp"}, "tmp", true, true},
+		{"anchored not nested", Patterns{"/tmp"}, "x/tmp", true, false},
+		{"slash anchors", Patterns{"x/tmp"}, "y/x/tmp", true, false},
+		{"dir only matches dir", Patterns{"cache/"}, "x/cache", true, true},
+		{"dir only skips file", Patterns{"cache/"}, "x/cache", false, false},
+		{"double star middle", Patterns{"a/**/c"}, "a/b/b/c", false, true},
+		{"double star zero segments", Patterns{"a/**/c"}, "a/c", false, true},
+		{"double star suffix", Patterns{"a/**"}, "a/b/c", false, true},
+		{"negated", Patterns{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"negated other", Patterns{"*.log", "!keep.log"}, "drop.log", false, true},
+		{"later pattern wins", Patterns{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"bad pattern", Patterns{"["}, "[", false, false},
+	}
+
+	for _, test := range tests {
+		if m := test.patterns.Match(test.rel, test.isDir); m != test.match {
+			t.Errorf("%s: Match(%q, %v): expected %v, got %v", test.name, test.rel, test.isDir, test.match, m)
+		}
+	}
+}
+
+func TestPatternsMatchParents(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns Patterns
+		rel      string
+		match    bool
+	}{
+		{"path", Patterns{"etc/hosts"}, "etc/hosts", true},
+		{"parent", Patterns{"etc/"}, "etc/ssh/sshd_config", true},
+		{"grandparent anchored", Patterns{"/etc"}, "etc/ssh/sshd_config", true},
+		{"no parent", Patterns{"/var"}, "etc/ssh/sshd_config", false},
+		{"file pattern not parent", Patterns{"etc"}, "x/etcetera", false},
+	}
+
+	for _, test := range tests {
+		if m := test.patterns.MatchParents(test.rel, false); m != test.match {
+			t.Errorf("%s: MatchParents(%q): expected %v, got %v", test.name, test.rel, test.match, m)
+		}
+	}
+}
@@ -2,21 +2,62 @@ package file
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 )
 
-//Parse parses the given json file into a Definition, or returns an error if one occurs
-func Parse(path string) (Definition, error) {
+//Parse parses the given json file into a Definition, or returns an error if one occurs.
+//A legacy definition (map[group]map[origin_path]destination_path) is upgraded to the current version
+func Parse(path string) (*Definition, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	var d Definition
+	var raw map[string]json.RawMessage
 
 	dec := json.NewDecoder(f)
-	err = dec.Decode(&d)
+	if err = dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	d := new(Definition)
+
+	//versioned definitions have a numeric version, where a legacy definition could only have a group named version
+	var version int
+	if v, ok := raw["version"]; ok && json.Unmarshal(v, &version) == nil {
+		if version != DefinitionVersion {
+			return nil, fmt.Errorf("unsupported definition version: %d", version)
+		}
+		d.Version = version
+		if g, ok := raw["groups"]; ok {
+			if err = json.Unmarshal(g, &d.Groups); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		d.Version = DefinitionVersion
+		d.Groups = make(map[string]map[string]*Mapping, len(raw))
+		for group, g := range raw {
+			var mapping map[string]*Mapping
+			if err = json.Unmarshal(g, &mapping); err != nil {
+				return nil, err
+			}
+			d.Groups[group] = mapping
+		}
+	}
+
+	for group, mapping := range d.Groups {
+		for origin, m := range mapping {
+			if m == nil {
+				return nil, fmt.Errorf("Group %s, Origin %s: no destination given", group, origin)
+			}
+			if err = m.validate(); err != nil {
+				return nil, fmt.Errorf("Group %s, Origin %s: %v", group, origin, err)
+			}
+		}
+	}
 
-	return d, err
+	return d, nil
 }
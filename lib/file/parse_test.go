@@ -0,0 +1,112 @@
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison-parse-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	//mapping returns a Mapping to dest with the default policies
+	mapping := func(dest string) *Mapping {
+		return &Mapping{Dest: dest, Symlinks: SymlinkSkip, Delete: DeleteRemove}
+	}
+
+	tests := []struct {
+		name string
+		json string
+		def  *Definition
+		err  bool
+	}{
+		{"legacy", `{"base": {"/srv/a": "/etc/a"}}`,
+			&Definition{Version: DefinitionVersion, Groups: map[string]map[string]*Mapping{"base": {"/srv/a": mapping("/etc/a")}}}, false},
+		{"legacy group named version", `{"version": {"/srv/a": "/etc/a"}, "groups": {"/srv/b": "/etc/b"}}`,
+			&Definition{Version: DefinitionVersion, Groups: map[string]map[string]*Mapping{
+				"version": {"/srv/a": mapping("/etc/a")},
+				"groups":  {"/srv/b": mapping("/etc/b")},
+			}}, false},
+		{"versioned", `{"version": 2, "groups": {"base": {
+			"/srv/a": "/etc/a",
+			"/srv/b": {"dest": "/etc/b", "mode": "4755", "owner": "root", "group": "wheel",
+				"include": ["*.conf"], "exclude": ["*.swp"], "symlinks": "follow", "delete": "quarantine"}
+		}}}`,
+			&Definition{Version: DefinitionVersion, Groups: map[string]map[string]*Mapping{"base": {
+				"/srv/a": mapping("/etc/a"),
+				"/srv/b": {Dest: "/etc/b", Mode: "4755", Owner: "root", Group: "wheel",
+					Include: Patterns{"*.conf"}, Exclude: Patterns{"*.swp"}, Symlinks: SymlinkFollow, Delete: DeleteQuarantine},
+			}}}, false},
+		{"versioned no groups", `{"version": 2}`, &Definition{Version: DefinitionVersion}, false},
+		{"unsupported version", `{"version": 3, "groups": {}}`, nil, true},
+		{"no destination", `{"version": 2, "groups": {"base": {"/srv/a": {"mode": "0644"}}}}`, nil, true},
+		{"null mapping", `{"base": {"/srv/a": null}}`, nil, true},
+		{"invalid mode", `{"version": 2, "groups": {"base": {"/srv/a": {"dest": "/etc/a", "mode": "0999"}}}}`, nil, true},
+		{"invalid symlink policy", `{"version": 2, "groups": {"base": {"/srv/a": {"dest": "/etc/a", "symlinks": "copy"}}}}`, nil, true},
+		{"invalid delete policy", `{"version": 2, "groups": {"base": {"/srv/a": {"dest": "/etc/a", "delete": "shred"}}}}`, nil, true},
+		{"invalid mapping", `{"base": {"/srv/a": 1}}`, nil, true},
+		{"invalid json", `{"base":`, nil, true},
+	}
+
+	for i, test := range tests {
+		path := filepath.Join(dir, strconv.Itoa(i)+".json")
+		if err = ioutil.WriteFile(path, []byte(test.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		def, err := Parse(path)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(def, test.def) {
+			got, _ := json.Marshal(def)
+			expected, _ := json.Marshal(test.def)
+			t.Errorf("%s: expected %s, got %s", test.name, expected, got)
+		}
+	}
+}
+
+func TestMappingFileMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		fileMode os.FileMode
+		ok       bool
+		err      bool
+	}{
+		{"", 0, false, false},
+		{"0644", 0644, true, false},
+		{"755", 0755, true, false},
+		{"4755", 0755 | os.ModeSetuid, true, false},
+		{"2750", 0750 | os.ModeSetgid, true, false},
+		{"1777", 0777 | os.ModeSticky, true, false},
+		{"7000", os.ModeSetuid | os.ModeSetgid | os.ModeSticky, true, false},
+		{"10000", 0, false, true},
+		{"0999", 0, false, true},
+		{"rwx", 0, false, true},
+	}
+
+	for _, test := range tests {
+		mode, ok, err := (&Mapping{Mode: test.mode}).FileMode()
+		if (err != nil) != test.err {
+			t.Errorf("%q: expected error %v, got %v", test.mode, test.err, err)
+			continue
+		}
+		if mode != test.fileMode || ok != test.ok {
+			t.Errorf("%q: expected %v, %v, got %v, %v", test.mode, test.fileMode, test.ok, mode, ok)
+		}
+	}
+}
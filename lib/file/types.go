@@ -1,29 +1,66 @@
 package file
 
 import (
-	"encoding/json"
-	"strconv"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
 )
 
-//Definition is a go representation of a json config:
-//map[group]map[origin_path]destination_path
-//origin_path and destination_path must both be files or both be directories
-type Definition map[string]map[string]string
+//Entry represents the content and metadata of a file in a Set
+type Entry struct {
+	Hash   uint64       //xxHash (64 bit)
+	Digest string       `json:",omitempty"` //algorithm tagged cryptographic digest, e.g. sha256:<hex>, if configured
+	Mode   os.FileMode  `json:",omitempty"` //permission, setuid, setgid and sticky bits
+	Owner  string       `json:",omitempty"` //user name, if ownership should be set
+	Group  string       `json:",omitempty"` //group name, if ownership should be set
+	Delete DeletePolicy `json:",omitempty"` //how the client handles the file once it's removed from the Set
+}
+
+//Set is a manifest mapping destination paths to content, map[path]*Entry.
+//Files with identical content appear once for every path they belong at
+type Set map[string]*Entry
 
-//Set is a mapping, map[xxHash (64 bit)]:path
-type Set map[uint64]string
+//Version returns a digest of the Set's entries sorted by path.
+//Any change to an entry's path, content or metadata results in a different version
+func (s Set) Version() uint64 {
+	paths := make([]string, 0, len(s))
+	for path := range s {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
 
-//MarshalJSON returns the JSON representation of the Set or an error if one occurs
-func (s Set) MarshalJSON() ([]byte, error) {
-	new := make(map[string]string)
-	for k, v := range s {
-		new[strconv.FormatUint(k, 10)] = v
+	h := sha256.New()
+	buf := make([]byte, 8)
+	for _, path := range paths {
+		e := s[path]
+		//strings are NUL terminated since they can't contain NUL, so entries can't run together
+		io.WriteString(h, path)
+		h.Write([]byte{0})
+		binary.BigEndian.PutUint64(buf, e.Hash)
+		h.Write(buf)
+		io.WriteString(h, e.Digest)
+		h.Write([]byte{0})
+		binary.BigEndian.PutUint32(buf, uint32(e.Mode))
+		h.Write(buf[:4])
+		io.WriteString(h, e.Owner)
+		h.Write([]byte{0})
+		io.WriteString(h, e.Group)
+		h.Write([]byte{0})
+		io.WriteString(h, string(e.Delete))
+		h.Write([]byte{0})
 	}
-	return json.Marshal(new)
+	return binary.BigEndian.Uint64(h.Sum(nil))
 }
 
-//VersionedSet is a Set grouped with it's version (sum of all hashes in set)
+//VersionedSet is a Set grouped with it's version (see Set.Version)
 type VersionedSet struct {
 	Set     Set
 	Version uint64
 }
+
+//NewVersionedSet returns a *VersionedSet for s
+func NewVersionedSet(s Set) *VersionedSet {
+	return &VersionedSet{Set: s, Version: s.Version()}
+}
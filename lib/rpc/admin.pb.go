@@ -0,0 +1,793 @@
+// Code generated by protoc-gen-go.
+// source: admin.proto
+// DO NOT EDIT!
+
+package rpc
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type ClientsRequest struct {
+	Location string `protobuf:"bytes,1,opt,name=location" json:"location,omitempty"`
+	Group    string `protobuf:"bytes,2,opt,name=group" json:"group,omitempty"`
+	Outdated bool   `protobuf:"varint,3,opt,name=outdated" json:"outdated,omitempty"`
+}
+
+func (m *ClientsRequest) Reset()                    { *m = ClientsRequest{} }
+func (m *ClientsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ClientsRequest) ProtoMessage()               {}
+func (*ClientsRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{0} }
+
+func (m *ClientsRequest) GetLocation() string {
+	if m != nil {
+		return m.Location
+	}
+	return ""
+}
+
+func (m *ClientsRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *ClientsRequest) GetOutdated() bool {
+	if m != nil {
+		return m.Outdated
+	}
+	return false
+}
+
+type Client struct {
+	HardwareAddr string            `protobuf:"bytes,1,opt,name=hardware_addr" json:"hardware_addr,omitempty"`
+	Location     string            `protobuf:"bytes,2,opt,name=location" json:"location,omitempty"`
+	LastSeen     int64             `protobuf:"varint,3,opt,name=last_seen" json:"last_seen,omitempty"`
+	Version      map[string]uint64 `protobuf:"bytes,4,rep,name=version" json:"version,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Outdated     []string          `protobuf:"bytes,5,rep,name=outdated" json:"outdated,omitempty"`
+	Drift        map[string]uint64 `protobuf:"bytes,6,rep,name=drift" json:"drift,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+}
+
+func (m *Client) Reset()                    { *m = Client{} }
+func (m *Client) String() string            { return proto.CompactTextString(m) }
+func (*Client) ProtoMessage()               {}
+func (*Client) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{1} }
+
+func (m *Client) GetHardwareAddr() string {
+	if m != nil {
+		return m.HardwareAddr
+	}
+	return ""
+}
+
+func (m *Client) GetLocation() string {
+	if m != nil {
+		return m.Location
+	}
+	return ""
+}
+
+func (m *Client) GetLastSeen() int64 {
+	if m != nil {
+		return m.LastSeen
+	}
+	return 0
+}
+
+func (m *Client) GetVersion() map[string]uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+func (m *Client) GetOutdated() []string {
+	if m != nil {
+		return m.Outdated
+	}
+	return nil
+}
+
+func (m *Client) GetDrift() map[string]uint64 {
+	if m != nil {
+		return m.Drift
+	}
+	return nil
+}
+
+type ClientsResponse struct {
+	Clients []*Client `protobuf:"bytes,1,rep,name=clients" json:"clients,omitempty"`
+}
+
+func (m *ClientsResponse) Reset()                    { *m = ClientsResponse{} }
+func (m *ClientsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ClientsResponse) ProtoMessage()               {}
+func (*ClientsResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{2} }
+
+func (m *ClientsResponse) GetClients() []*Client {
+	if m != nil {
+		return m.Clients
+	}
+	return nil
+}
+
+type PresenceRequest struct {
+	Stale bool `protobuf:"varint,1,opt,name=stale" json:"stale,omitempty"`
+}
+
+func (m *PresenceRequest) Reset()                    { *m = PresenceRequest{} }
+func (m *PresenceRequest) String() string            { return proto.CompactTextString(m) }
+func (*PresenceRequest) ProtoMessage()               {}
+func (*PresenceRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{3} }
+
+func (m *PresenceRequest) GetStale() bool {
+	if m != nil {
+		return m.Stale
+	}
+	return false
+}
+
+type Presence struct {
+	HardwareAddr   string `protobuf:"bytes,1,opt,name=hardware_addr" json:"hardware_addr,omitempty"`
+	Connected      bool   `protobuf:"varint,2,opt,name=connected" json:"connected,omitempty"`
+	ConnectedAt    int64  `protobuf:"varint,3,opt,name=connected_at" json:"connected_at,omitempty"`
+	DisconnectedAt int64  `protobuf:"varint,4,opt,name=disconnected_at" json:"disconnected_at,omitempty"`
+	LastReport     int64  `protobuf:"varint,5,opt,name=last_report" json:"last_report,omitempty"`
+	ReportInterval int64  `protobuf:"varint,6,opt,name=report_interval" json:"report_interval,omitempty"`
+	Stale          bool   `protobuf:"varint,7,opt,name=stale" json:"stale,omitempty"`
+}
+
+func (m *Presence) Reset()                    { *m = Presence{} }
+func (m *Presence) String() string            { return proto.CompactTextString(m) }
+func (*Presence) ProtoMessage()               {}
+func (*Presence) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{4} }
+
+func (m *Presence) GetHardwareAddr() string {
+	if m != nil {
+		return m.HardwareAddr
+	}
+	return ""
+}
+
+func (m *Presence) GetConnected() bool {
+	if m != nil {
+		return m.Connected
+	}
+	return false
+}
+
+func (m *Presence) GetConnectedAt() int64 {
+	if m != nil {
+		return m.ConnectedAt
+	}
+	return 0
+}
+
+func (m *Presence) GetDisconnectedAt() int64 {
+	if m != nil {
+		return m.DisconnectedAt
+	}
+	return 0
+}
+
+func (m *Presence) GetLastReport() int64 {
+	if m != nil {
+		return m.LastReport
+	}
+	return 0
+}
+
+func (m *Presence) GetReportInterval() int64 {
+	if m != nil {
+		return m.ReportInterval
+	}
+	return 0
+}
+
+func (m *Presence) GetStale() bool {
+	if m != nil {
+		return m.Stale
+	}
+	return false
+}
+
+type PresenceResponse struct {
+	Clients []*Presence `protobuf:"bytes,1,rep,name=clients" json:"clients,omitempty"`
+}
+
+func (m *PresenceResponse) Reset()                    { *m = PresenceResponse{} }
+func (m *PresenceResponse) String() string            { return proto.CompactTextString(m) }
+func (*PresenceResponse) ProtoMessage()               {}
+func (*PresenceResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{5} }
+
+func (m *PresenceResponse) GetClients() []*Presence {
+	if m != nil {
+		return m.Clients
+	}
+	return nil
+}
+
+type DeleteClientRequest struct {
+	HardwareAddr string `protobuf:"bytes,1,opt,name=hardware_addr" json:"hardware_addr,omitempty"`
+}
+
+func (m *DeleteClientRequest) Reset()                    { *m = DeleteClientRequest{} }
+func (m *DeleteClientRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteClientRequest) ProtoMessage()               {}
+func (*DeleteClientRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{6} }
+
+func (m *DeleteClientRequest) GetHardwareAddr() string {
+	if m != nil {
+		return m.HardwareAddr
+	}
+	return ""
+}
+
+type DeleteClientResponse struct {
+}
+
+func (m *DeleteClientResponse) Reset()                    { *m = DeleteClientResponse{} }
+func (m *DeleteClientResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeleteClientResponse) ProtoMessage()               {}
+func (*DeleteClientResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{7} }
+
+type ReloadRequest struct {
+	DryRun bool `protobuf:"varint,1,opt,name=dry_run" json:"dry_run,omitempty"`
+}
+
+func (m *ReloadRequest) Reset()                    { *m = ReloadRequest{} }
+func (m *ReloadRequest) String() string            { return proto.CompactTextString(m) }
+func (*ReloadRequest) ProtoMessage()               {}
+func (*ReloadRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{8} }
+
+func (m *ReloadRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+type GroupDiff struct {
+	Group      string   `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	OldVersion uint64   `protobuf:"varint,2,opt,name=old_version" json:"old_version,omitempty"`
+	NewVersion uint64   `protobuf:"varint,3,opt,name=new_version" json:"new_version,omitempty"`
+	Added      []string `protobuf:"bytes,4,rep,name=added" json:"added,omitempty"`
+	Removed    []string `protobuf:"bytes,5,rep,name=removed" json:"removed,omitempty"`
+	Modified   []string `protobuf:"bytes,6,rep,name=modified" json:"modified,omitempty"`
+}
+
+func (m *GroupDiff) Reset()                    { *m = GroupDiff{} }
+func (m *GroupDiff) String() string            { return proto.CompactTextString(m) }
+func (*GroupDiff) ProtoMessage()               {}
+func (*GroupDiff) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{9} }
+
+func (m *GroupDiff) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *GroupDiff) GetOldVersion() uint64 {
+	if m != nil {
+		return m.OldVersion
+	}
+	return 0
+}
+
+func (m *GroupDiff) GetNewVersion() uint64 {
+	if m != nil {
+		return m.NewVersion
+	}
+	return 0
+}
+
+func (m *GroupDiff) GetAdded() []string {
+	if m != nil {
+		return m.Added
+	}
+	return nil
+}
+
+func (m *GroupDiff) GetRemoved() []string {
+	if m != nil {
+		return m.Removed
+	}
+	return nil
+}
+
+func (m *GroupDiff) GetModified() []string {
+	if m != nil {
+		return m.Modified
+	}
+	return nil
+}
+
+type ReloadResponse struct {
+	Groups         []*GroupDiff      `protobuf:"bytes,1,rep,name=groups" json:"groups,omitempty"`
+	DryRun         bool              `protobuf:"varint,2,opt,name=dry_run" json:"dry_run,omitempty"`
+	Warnings       []string          `protobuf:"bytes,3,rep,name=warnings" json:"warnings,omitempty"`
+	Errors         map[string]string `protobuf:"bytes,4,rep,name=errors" json:"errors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	NotifyFailures int64             `protobuf:"varint,5,opt,name=notify_failures" json:"notify_failures,omitempty"`
+}
+
+func (m *ReloadResponse) Reset()                    { *m = ReloadResponse{} }
+func (m *ReloadResponse) String() string            { return proto.CompactTextString(m) }
+func (*ReloadResponse) ProtoMessage()               {}
+func (*ReloadResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{10} }
+
+func (m *ReloadResponse) GetGroups() []*GroupDiff {
+	if m != nil {
+		return m.Groups
+	}
+	return nil
+}
+
+func (m *ReloadResponse) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+func (m *ReloadResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
+func (m *ReloadResponse) GetErrors() map[string]string {
+	if m != nil {
+		return m.Errors
+	}
+	return nil
+}
+
+func (m *ReloadResponse) GetNotifyFailures() int64 {
+	if m != nil {
+		return m.NotifyFailures
+	}
+	return 0
+}
+
+type HistoryRequest struct {
+	Group string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+}
+
+func (m *HistoryRequest) Reset()                    { *m = HistoryRequest{} }
+func (m *HistoryRequest) String() string            { return proto.CompactTextString(m) }
+func (*HistoryRequest) ProtoMessage()               {}
+func (*HistoryRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{11} }
+
+func (m *HistoryRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+type HistoryVersion struct {
+	Version   uint64 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Time      int64  `protobuf:"varint,2,opt,name=time" json:"time,omitempty"`
+	Files     int64  `protobuf:"varint,3,opt,name=files" json:"files,omitempty"`
+	Published bool   `protobuf:"varint,4,opt,name=published" json:"published,omitempty"`
+}
+
+func (m *HistoryVersion) Reset()                    { *m = HistoryVersion{} }
+func (m *HistoryVersion) String() string            { return proto.CompactTextString(m) }
+func (*HistoryVersion) ProtoMessage()               {}
+func (*HistoryVersion) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{12} }
+
+func (m *HistoryVersion) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *HistoryVersion) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *HistoryVersion) GetFiles() int64 {
+	if m != nil {
+		return m.Files
+	}
+	return 0
+}
+
+func (m *HistoryVersion) GetPublished() bool {
+	if m != nil {
+		return m.Published
+	}
+	return false
+}
+
+type HistoryResponse struct {
+	Versions []*HistoryVersion `protobuf:"bytes,1,rep,name=versions" json:"versions,omitempty"`
+}
+
+func (m *HistoryResponse) Reset()                    { *m = HistoryResponse{} }
+func (m *HistoryResponse) String() string            { return proto.CompactTextString(m) }
+func (*HistoryResponse) ProtoMessage()               {}
+func (*HistoryResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{13} }
+
+func (m *HistoryResponse) GetVersions() []*HistoryVersion {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type RollbackRequest struct {
+	Group   string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	Version uint64 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *RollbackRequest) Reset()                    { *m = RollbackRequest{} }
+func (m *RollbackRequest) String() string            { return proto.CompactTextString(m) }
+func (*RollbackRequest) ProtoMessage()               {}
+func (*RollbackRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{14} }
+
+func (m *RollbackRequest) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *RollbackRequest) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type RollbackResponse struct {
+	Diff           *GroupDiff `protobuf:"bytes,1,opt,name=diff" json:"diff,omitempty"`
+	NotifyFailures int64      `protobuf:"varint,2,opt,name=notify_failures" json:"notify_failures,omitempty"`
+}
+
+func (m *RollbackResponse) Reset()                    { *m = RollbackResponse{} }
+func (m *RollbackResponse) String() string            { return proto.CompactTextString(m) }
+func (*RollbackResponse) ProtoMessage()               {}
+func (*RollbackResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{15} }
+
+func (m *RollbackResponse) GetDiff() *GroupDiff {
+	if m != nil {
+		return m.Diff
+	}
+	return nil
+}
+
+func (m *RollbackResponse) GetNotifyFailures() int64 {
+	if m != nil {
+		return m.NotifyFailures
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*ClientsRequest)(nil), "rpc.ClientsRequest")
+	proto.RegisterType((*Client)(nil), "rpc.Client")
+	proto.RegisterType((*ClientsResponse)(nil), "rpc.ClientsResponse")
+	proto.RegisterType((*PresenceRequest)(nil), "rpc.PresenceRequest")
+	proto.RegisterType((*Presence)(nil), "rpc.Presence")
+	proto.RegisterType((*PresenceResponse)(nil), "rpc.PresenceResponse")
+	proto.RegisterType((*DeleteClientRequest)(nil), "rpc.DeleteClientRequest")
+	proto.RegisterType((*DeleteClientResponse)(nil), "rpc.DeleteClientResponse")
+	proto.RegisterType((*ReloadRequest)(nil), "rpc.ReloadRequest")
+	proto.RegisterType((*GroupDiff)(nil), "rpc.GroupDiff")
+	proto.RegisterType((*ReloadResponse)(nil), "rpc.ReloadResponse")
+	proto.RegisterType((*HistoryRequest)(nil), "rpc.HistoryRequest")
+	proto.RegisterType((*HistoryVersion)(nil), "rpc.HistoryVersion")
+	proto.RegisterType((*HistoryResponse)(nil), "rpc.HistoryResponse")
+	proto.RegisterType((*RollbackRequest)(nil), "rpc.RollbackRequest")
+	proto.RegisterType((*RollbackResponse)(nil), "rpc.RollbackResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Admin service
+
+type AdminClient interface {
+	Clients(ctx context.Context, in *ClientsRequest, opts ...grpc.CallOption) (*ClientsResponse, error)
+	Presence(ctx context.Context, in *PresenceRequest, opts ...grpc.CallOption) (*PresenceResponse, error)
+	DeleteClient(ctx context.Context, in *DeleteClientRequest, opts ...grpc.CallOption) (*DeleteClientResponse, error)
+	Reload(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error)
+	History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+	Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*RollbackResponse, error)
+}
+
+type adminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) Clients(ctx context.Context, in *ClientsRequest, opts ...grpc.CallOption) (*ClientsResponse, error) {
+	out := new(ClientsResponse)
+	err := grpc.Invoke(ctx, "/rpc.Admin/Clients", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Presence(ctx context.Context, in *PresenceRequest, opts ...grpc.CallOption) (*PresenceResponse, error) {
+	out := new(PresenceResponse)
+	err := grpc.Invoke(ctx, "/rpc.Admin/Presence", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteClient(ctx context.Context, in *DeleteClientRequest, opts ...grpc.CallOption) (*DeleteClientResponse, error) {
+	out := new(DeleteClientResponse)
+	err := grpc.Invoke(ctx, "/rpc.Admin/DeleteClient", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Reload(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error) {
+	out := new(ReloadResponse)
+	err := grpc.Invoke(ctx, "/rpc.Admin/Reload", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	out := new(HistoryResponse)
+	err := grpc.Invoke(ctx, "/rpc.Admin/History", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*RollbackResponse, error) {
+	out := new(RollbackResponse)
+	err := grpc.Invoke(ctx, "/rpc.Admin/Rollback", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Admin service
+
+type AdminServer interface {
+	Clients(context.Context, *ClientsRequest) (*ClientsResponse, error)
+	Presence(context.Context, *PresenceRequest) (*PresenceResponse, error)
+	DeleteClient(context.Context, *DeleteClientRequest) (*DeleteClientResponse, error)
+	Reload(context.Context, *ReloadRequest) (*ReloadResponse, error)
+	History(context.Context, *HistoryRequest) (*HistoryResponse, error)
+	Rollback(context.Context, *RollbackRequest) (*RollbackResponse, error)
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_Clients_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Clients(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Admin/Clients",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Clients(ctx, req.(*ClientsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Presence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PresenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Presence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Admin/Presence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Presence(ctx, req.(*PresenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteClientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Admin/DeleteClient",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteClient(ctx, req.(*DeleteClientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Reload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Admin/Reload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Reload(ctx, req.(*ReloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Admin/History",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).History(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Rollback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Rollback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.Admin/Rollback",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Rollback(ctx, req.(*RollbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpc.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Clients",
+			Handler:    _Admin_Clients_Handler,
+		},
+		{
+			MethodName: "Presence",
+			Handler:    _Admin_Presence_Handler,
+		},
+		{
+			MethodName: "DeleteClient",
+			Handler:    _Admin_DeleteClient_Handler,
+		},
+		{
+			MethodName: "Reload",
+			Handler:    _Admin_Reload_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _Admin_History_Handler,
+		},
+		{
+			MethodName: "Rollback",
+			Handler:    _Admin_Rollback_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
+
+func init() { proto.RegisterFile("admin.proto", fileDescriptor2) }
+
+var fileDescriptor2 = []byte{
+	// 754 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0xdd, 0x4e, 0xdb, 0x48,
+	0x14, 0x96, 0xe3, 0xfc, 0xd8, 0x27, 0xbf, 0x38, 0x01, 0xbc, 0xd1, 0x6a, 0x89, 0x2c, 0x21, 0x65,
+	0x25, 0x36, 0x08, 0xb6, 0x12, 0xa8, 0x77, 0x15, 0xa0, 0xb6, 0x77, 0x2d, 0x17, 0xbd, 0x8d, 0x06,
+	0xcf, 0x18, 0x46, 0x38, 0x9e, 0x74, 0x66, 0x12, 0x94, 0x17, 0xe8, 0x73, 0xf4, 0x81, 0xfa, 0x0a,
+	0x7d, 0x97, 0xca, 0x67, 0x6c, 0xc7, 0x4e, 0x83, 0x7a, 0xe9, 0xf3, 0xff, 0x7d, 0xe7, 0x3b, 0x63,
+	0x68, 0x13, 0xba, 0xe0, 0xc9, 0x6c, 0x29, 0x85, 0x16, 0x9e, 0x2d, 0x97, 0x61, 0x70, 0x03, 0xbd,
+	0x9b, 0x98, 0xb3, 0x44, 0xab, 0x7b, 0xf6, 0x75, 0xc5, 0x94, 0xf6, 0x06, 0xe0, 0xc4, 0x22, 0x24,
+	0x9a, 0x8b, 0xc4, 0xb7, 0x26, 0xd6, 0xd4, 0xf5, 0xba, 0xd0, 0x78, 0x94, 0x62, 0xb5, 0xf4, 0x6b,
+	0xf8, 0x39, 0x00, 0x47, 0xac, 0x34, 0x25, 0x9a, 0x51, 0xdf, 0x9e, 0x58, 0x53, 0x27, 0xf8, 0x56,
+	0x83, 0xa6, 0xa9, 0xe2, 0x1d, 0x42, 0xf7, 0x89, 0x48, 0xfa, 0x42, 0x24, 0x9b, 0x13, 0x4a, 0x65,
+	0x56, 0xa2, 0x5c, 0xd4, 0x54, 0x39, 0x00, 0x37, 0x26, 0x4a, 0xcf, 0x15, 0x63, 0x09, 0x96, 0xb1,
+	0xbd, 0x7f, 0xa1, 0xb5, 0x66, 0x52, 0xa5, 0x31, 0xf5, 0x89, 0x3d, 0x6d, 0x5f, 0xfa, 0x33, 0xb9,
+	0x0c, 0x67, 0xa6, 0xf2, 0xec, 0x8b, 0x71, 0xdd, 0x25, 0x5a, 0x6e, 0x2a, 0x33, 0x34, 0x26, 0xf6,
+	0xd4, 0xf5, 0x4e, 0xa1, 0x41, 0x25, 0x8f, 0xb4, 0xdf, 0xc4, 0xd4, 0xa3, 0x72, 0xea, 0x6d, 0xea,
+	0xc0, 0xc4, 0xf1, 0x0c, 0x3a, 0x95, 0x42, 0x6d, 0xb0, 0x9f, 0xd9, 0x66, 0x0b, 0x74, 0x4d, 0xe2,
+	0x15, 0xc3, 0x11, 0xeb, 0x6f, 0x6b, 0xd7, 0xd6, 0xf8, 0x0c, 0x60, 0x9b, 0xfd, 0xa7, 0xe8, 0xe0,
+	0x1c, 0xfa, 0x05, 0x9b, 0x6a, 0x29, 0x12, 0xc5, 0xbc, 0xbf, 0xa1, 0x15, 0x1a, 0x93, 0x6f, 0xe1,
+	0x64, 0xed, 0xd2, 0x64, 0xc1, 0x04, 0xfa, 0x9f, 0x24, 0x53, 0x2c, 0x09, 0x59, 0xce, 0x7f, 0x17,
+	0x1a, 0x4a, 0x93, 0x98, 0x61, 0x17, 0x27, 0xf8, 0x6e, 0x81, 0x93, 0x87, 0xbc, 0xc6, 0xee, 0x01,
+	0xb8, 0xa1, 0x48, 0x12, 0x16, 0xa6, 0x74, 0xa4, 0xd3, 0x38, 0xde, 0x08, 0x3a, 0x85, 0x69, 0x4e,
+	0x74, 0xc6, 0xf0, 0x31, 0xf4, 0x29, 0x57, 0x15, 0x47, 0x1d, 0x1d, 0x43, 0x68, 0xe3, 0x36, 0x24,
+	0x5b, 0x0a, 0xa9, 0xfd, 0x46, 0x1e, 0x6d, 0xbe, 0xe7, 0x3c, 0xd1, 0x4c, 0xae, 0x49, 0xec, 0x37,
+	0xd1, 0x51, 0x8c, 0xd8, 0xc2, 0x11, 0x2f, 0x61, 0xb0, 0x05, 0x91, 0xc1, 0xfe, 0x67, 0x17, 0x76,
+	0x17, 0x61, 0xe7, 0x71, 0xc1, 0x19, 0x0c, 0x6f, 0x59, 0xcc, 0x34, 0x33, 0x44, 0xe4, 0xe0, 0xf7,
+	0x03, 0x0c, 0x8e, 0x60, 0x54, 0x8d, 0x36, 0x5d, 0x82, 0x09, 0x74, 0xef, 0x59, 0x2c, 0x08, 0xcd,
+	0xf3, 0xfb, 0xd0, 0xa2, 0x72, 0x33, 0x97, 0xab, 0x24, 0xa3, 0x6f, 0x0d, 0xee, 0xfb, 0x54, 0xbb,
+	0xb7, 0x3c, 0x8a, 0xb6, 0x42, 0x36, 0xb4, 0x0d, 0xa1, 0x2d, 0x62, 0x3a, 0xcf, 0x35, 0x87, 0x6b,
+	0x4c, 0x8d, 0x09, 0x7b, 0x29, 0x8c, 0x36, 0x1a, 0xbb, 0xd0, 0x20, 0x94, 0x32, 0x8a, 0xba, 0x74,
+	0xd3, 0x2e, 0x92, 0x2d, 0xc4, 0xba, 0x10, 0xdf, 0x00, 0x9c, 0x85, 0xa0, 0x3c, 0xe2, 0x8c, 0xa2,
+	0xfe, 0xdc, 0xe0, 0x87, 0x05, 0xbd, 0x7c, 0xb4, 0x82, 0x92, 0x26, 0x76, 0xcf, 0x19, 0xe9, 0x21,
+	0x23, 0xdb, 0xe9, 0x4a, 0xb3, 0x9b, 0x1d, 0x0e, 0xc0, 0x79, 0x21, 0x32, 0xe1, 0xc9, 0xa3, 0xf2,
+	0x6d, 0xec, 0x73, 0x0e, 0x4d, 0x26, 0xa5, 0x90, 0x2a, 0x3b, 0x90, 0x13, 0x2c, 0x51, 0xed, 0x33,
+	0xbb, 0xc3, 0x08, 0x23, 0xd8, 0x63, 0xe8, 0x27, 0x42, 0xf3, 0x68, 0x33, 0x8f, 0x08, 0x8f, 0x57,
+	0x92, 0x29, 0xb3, 0xdb, 0xf1, 0x7f, 0xd0, 0x2e, 0xc7, 0xbd, 0x2e, 0x6c, 0x17, 0x85, 0x7d, 0x02,
+	0xbd, 0x0f, 0x5c, 0x69, 0x21, 0x37, 0x25, 0x99, 0x96, 0xb8, 0x0c, 0x3e, 0x17, 0x01, 0xd9, 0x79,
+	0xa5, 0x70, 0x72, 0x12, 0x2d, 0x24, 0xb1, 0x03, 0x75, 0xcd, 0x17, 0xa6, 0x2a, 0x6a, 0x28, 0xe2,
+	0x31, 0x53, 0x99, 0x32, 0x0f, 0xc0, 0x5d, 0xae, 0x1e, 0x62, 0xae, 0x9e, 0x90, 0xe5, 0x74, 0x75,
+	0xd7, 0xd0, 0x2f, 0x7a, 0x66, 0x14, 0x9e, 0x82, 0x93, 0xd5, 0xcc, 0x49, 0x1c, 0x22, 0x03, 0xd5,
+	0xd6, 0xc1, 0x05, 0xf4, 0xef, 0x45, 0x1c, 0x3f, 0x90, 0xf0, 0x79, 0xff, 0xb8, 0xe5, 0xe1, 0x70,
+	0xed, 0xc1, 0x47, 0x18, 0x6c, 0x53, 0x8a, 0xd3, 0xad, 0x53, 0x1e, 0x45, 0x98, 0xf2, 0xfb, 0xba,
+	0xf6, 0x50, 0x8b, 0xc8, 0x2e, 0x7f, 0xd6, 0xa0, 0xf1, 0x2e, 0x7d, 0x67, 0xbd, 0x37, 0xd0, 0xca,
+	0x9e, 0x03, 0x6f, 0x58, 0xba, 0xfa, 0xfc, 0xa9, 0x1d, 0x8f, 0xaa, 0xc6, 0xac, 0xed, 0x55, 0xe9,
+	0xe0, 0x47, 0x95, 0xab, 0xc9, 0xf3, 0x0e, 0x77, 0xac, 0x59, 0xe2, 0x0d, 0x74, 0xca, 0x57, 0xe2,
+	0x99, 0xe7, 0x73, 0xcf, 0x99, 0x8d, 0xff, 0xda, 0xe3, 0xc9, 0x8a, 0x5c, 0x40, 0xd3, 0xe8, 0xc9,
+	0xf3, 0x2a, 0xe2, 0x32, 0x89, 0xc3, 0x3d, 0x82, 0x4b, 0x61, 0x66, 0x0b, 0xf0, 0x2a, 0xeb, 0xa8,
+	0xc2, 0xdc, 0xdd, 0xe5, 0x15, 0x38, 0x39, 0xe3, 0x19, 0xcc, 0x9d, 0x9d, 0x8d, 0x0f, 0x77, 0xac,
+	0x26, 0xf1, 0xa1, 0x89, 0xbf, 0xaf, 0xff, 0x7f, 0x0d, 0x00, 0xa3, 0x0e, 0x2f, 0x5e, 0xcd, 0x06,
+	0x00, 0x00,
+}
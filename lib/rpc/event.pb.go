@@ -8,12 +8,31 @@ Package rpc is a generated protocol buffer package.
 It is generated from these files:
 	event.proto
 	files.proto
+	admin.proto
 
 It has these top-level messages:
 	Report
 	Notification
 	FileSetRequest
 	FileSetResponse
+	FileRequest
+	FileChunk
+	ClientsRequest
+	Client
+	ClientsResponse
+	PresenceRequest
+	Presence
+	PresenceResponse
+	DeleteClientRequest
+	DeleteClientResponse
+	ReloadRequest
+	GroupDiff
+	ReloadResponse
+	HistoryRequest
+	HistoryVersion
+	HistoryResponse
+	RollbackRequest
+	RollbackResponse
 */
 package rpc
 
@@ -41,6 +60,7 @@ type Report struct {
 	HardwareAddr string            `protobuf:"bytes,2,opt,name=hardware_addr" json:"hardware_addr,omitempty"`
 	Location     string            `protobuf:"bytes,3,opt,name=location" json:"location,omitempty"`
 	Version      map[string]uint64 `protobuf:"bytes,4,rep,name=version" json:"version,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Drift        map[string]uint64 `protobuf:"bytes,5,rep,name=drift" json:"drift,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
 }
 
 func (m *Report) Reset()                    { *m = Report{} }
@@ -69,6 +89,13 @@ func (m *Report) GetVersion() map[string]uint64 {
 	return nil
 }
 
+func (m *Report) GetDrift() map[string]uint64 {
+	if m != nil {
+		return m.Drift
+	}
+	return nil
+}
+
 type Notification struct {
 	Group   string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
 	Version uint64 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
@@ -205,19 +232,21 @@ var _Events_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("event.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 219 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x4c, 0x90, 0x41, 0x4b, 0xc4, 0x30,
-	0x10, 0x85, 0xc9, 0x66, 0x8d, 0x3a, 0xd9, 0x45, 0x0d, 0x08, 0xa1, 0xa7, 0xd2, 0x53, 0x05, 0x09,
-	0x52, 0x41, 0xc4, 0x7b, 0xaf, 0x1e, 0x14, 0xbc, 0x4a, 0x6c, 0x47, 0x2d, 0xd6, 0x26, 0x4c, 0xd3,
-	0x4a, 0xff, 0x83, 0x3f, 0x5a, 0x9a, 0x0a, 0xdb, 0xe3, 0x7b, 0xc9, 0x7b, 0xdf, 0xcc, 0x80, 0xc4,
-	0x11, 0xbb, 0x60, 0x3c, 0xb9, 0xe0, 0x14, 0x27, 0x5f, 0x65, 0xbf, 0x0c, 0xc4, 0x13, 0x7a, 0x47,
-	0x41, 0x5d, 0xc2, 0xfe, 0xd3, 0x52, 0xfd, 0x63, 0x09, 0x5f, 0x6d, 0x5d, 0x93, 0xde, 0xa4, 0x2c,
-	0x3f, 0x55, 0xe7, 0x70, 0xd2, 0xba, 0xca, 0x86, 0xc6, 0x75, 0x9a, 0x47, 0xe7, 0x0a, 0x8e, 0x47,
-	0xa4, 0x7e, 0x36, 0xb6, 0x29, 0xcf, 0x65, 0xa1, 0x0d, 0xf9, 0xca, 0x2c, 0x35, 0xe6, 0x65, 0x79,
-	0x2a, 0xbb, 0x40, 0x53, 0x62, 0x60, 0xb7, 0xd6, 0x4a, 0x02, 0xff, 0xc2, 0x49, 0xb3, 0xd8, 0xb3,
-	0x87, 0xa3, 0xd1, 0xb6, 0x03, 0x46, 0xd0, 0xf6, 0x61, 0x73, 0xcf, 0x32, 0x03, 0xbb, 0x47, 0x17,
-	0x9a, 0xf7, 0x66, 0x01, 0xce, 0x5f, 0x3e, 0xc8, 0x0d, 0xfe, 0x3f, 0x71, 0x76, 0x20, 0xc7, 0x4c,
-	0x71, 0x07, 0xa2, 0x9c, 0x57, 0xea, 0xd5, 0x35, 0x88, 0xe7, 0x40, 0x68, 0xbf, 0x95, 0x5c, 0x4d,
-	0x93, 0x5c, 0x44, 0xb1, 0xee, 0xcc, 0xd9, 0x0d, 0x7b, 0x13, 0xf1, 0x04, 0xb7, 0x7f, 0x01, 0x00,
-	0x00, 0xff, 0xff, 0xda, 0xeb, 0xb0, 0x68, 0x11, 0x01, 0x00, 0x00,
+	// 242 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x90, 0xc1, 0x4a, 0xc3, 0x40,
+	0x10, 0x86, 0xd9, 0xa6, 0x89, 0x3a, 0x69, 0x51, 0x17, 0x94, 0x25, 0xa7, 0x52, 0x10, 0x22, 0x94,
+	0x45, 0x2a, 0x88, 0x78, 0xb6, 0x57, 0x0f, 0x0a, 0x5e, 0x65, 0x4d, 0xa6, 0x1a, 0xac, 0xd9, 0x65,
+	0x3a, 0x8d, 0xf4, 0x71, 0x7d, 0x13, 0xc9, 0x44, 0x48, 0x3c, 0x79, 0xdc, 0x99, 0xef, 0x9b, 0x9d,
+	0xf9, 0x21, 0xc5, 0x06, 0x6b, 0xb6, 0x81, 0x3c, 0x7b, 0x1d, 0x51, 0x28, 0xe6, 0xdf, 0x0a, 0x92,
+	0x47, 0x0c, 0x9e, 0x58, 0x9f, 0xc1, 0xf4, 0xdd, 0x51, 0xf9, 0xe5, 0x08, 0x5f, 0x5c, 0x59, 0x92,
+	0x19, 0xcd, 0x54, 0x7e, 0xa4, 0x4f, 0xe0, 0x70, 0xe3, 0x0b, 0xc7, 0x95, 0xaf, 0x4d, 0x24, 0x95,
+	0x4b, 0x38, 0x68, 0x90, 0xb6, 0x6d, 0x61, 0x3c, 0x8b, 0xf2, 0x74, 0x69, 0x2c, 0x85, 0xc2, 0x76,
+	0x63, 0xec, 0x73, 0xd7, 0x5a, 0xd5, 0x4c, 0x7b, 0x7d, 0x01, 0x71, 0x49, 0xd5, 0x9a, 0x4d, 0x2c,
+	0xe0, 0xf9, 0x10, 0xbc, 0x6f, 0x1b, 0x82, 0x65, 0x16, 0x26, 0x7f, 0xb4, 0x14, 0xa2, 0x0f, 0xdc,
+	0x1b, 0x25, 0xdf, 0x4d, 0x21, 0x6e, 0xdc, 0x66, 0x87, 0xb2, 0xcf, 0xf8, 0x6e, 0x74, 0xab, 0xb2,
+	0x05, 0x40, 0x6f, 0xff, 0x47, 0xcf, 0x2d, 0x4c, 0x1e, 0x3c, 0x57, 0xeb, 0xaa, 0xbb, 0xa2, 0x45,
+	0xde, 0xc8, 0xef, 0xc2, 0xaf, 0x71, 0xdc, 0x9f, 0x23, 0xce, 0xf2, 0x06, 0x92, 0x55, 0x9b, 0xd3,
+	0x56, 0x2f, 0x20, 0x79, 0x62, 0x42, 0xf7, 0xa9, 0xd3, 0xc1, 0xe6, 0xd9, 0xa9, 0x3c, 0x86, 0x33,
+	0x73, 0x75, 0xa5, 0x5e, 0x13, 0xc9, 0xf5, 0xfa, 0x67, 0x00, 0xe9, 0xcf, 0x79, 0xf7, 0x66, 0x01,
+	0x00, 0x00,
 }
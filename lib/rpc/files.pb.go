@@ -50,15 +50,73 @@ func (m *FileSetResponse) GetSets() map[string]*FileSetResponse_VersionedSet {
 	return nil
 }
 
+type FileSetResponse_File struct {
+	Hash   uint64 `protobuf:"varint,1,opt,name=hash" json:"hash,omitempty"`
+	Digest string `protobuf:"bytes,2,opt,name=digest" json:"digest,omitempty"`
+	Mode   uint32 `protobuf:"varint,3,opt,name=mode" json:"mode,omitempty"`
+	Owner  string `protobuf:"bytes,4,opt,name=owner" json:"owner,omitempty"`
+	Group  string `protobuf:"bytes,5,opt,name=group" json:"group,omitempty"`
+	Delete string `protobuf:"bytes,6,opt,name=delete" json:"delete,omitempty"`
+}
+
+func (m *FileSetResponse_File) Reset()                    { *m = FileSetResponse_File{} }
+func (m *FileSetResponse_File) String() string            { return proto.CompactTextString(m) }
+func (*FileSetResponse_File) ProtoMessage()               {}
+func (*FileSetResponse_File) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{1, 0} }
+
+func (m *FileSetResponse_File) GetHash() uint64 {
+	if m != nil {
+		return m.Hash
+	}
+	return 0
+}
+
+func (m *FileSetResponse_File) GetDigest() string {
+	if m != nil {
+		return m.Digest
+	}
+	return ""
+}
+
+func (m *FileSetResponse_File) GetMode() uint32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+func (m *FileSetResponse_File) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *FileSetResponse_File) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *FileSetResponse_File) GetDelete() string {
+	if m != nil {
+		return m.Delete
+	}
+	return ""
+}
+
 type FileSetResponse_VersionedSet struct {
-	Version uint64            `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
-	Set     map[uint64]string `protobuf:"bytes,2,rep,name=set" json:"set,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Version uint64                           `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Files   map[string]*FileSetResponse_File `protobuf:"bytes,3,rep,name=files" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
-func (m *FileSetResponse_VersionedSet) Reset()                    { *m = FileSetResponse_VersionedSet{} }
-func (m *FileSetResponse_VersionedSet) String() string            { return proto.CompactTextString(m) }
-func (*FileSetResponse_VersionedSet) ProtoMessage()               {}
-func (*FileSetResponse_VersionedSet) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{1, 0} }
+func (m *FileSetResponse_VersionedSet) Reset()         { *m = FileSetResponse_VersionedSet{} }
+func (m *FileSetResponse_VersionedSet) String() string { return proto.CompactTextString(m) }
+func (*FileSetResponse_VersionedSet) ProtoMessage()    {}
+func (*FileSetResponse_VersionedSet) Descriptor() ([]byte, []int) {
+	return fileDescriptor1, []int{1, 1}
+}
 
 func (m *FileSetResponse_VersionedSet) GetVersion() uint64 {
 	if m != nil {
@@ -67,9 +125,41 @@ func (m *FileSetResponse_VersionedSet) GetVersion() uint64 {
 	return 0
 }
 
-func (m *FileSetResponse_VersionedSet) GetSet() map[uint64]string {
+func (m *FileSetResponse_VersionedSet) GetFiles() map[string]*FileSetResponse_File {
 	if m != nil {
-		return m.Set
+		return m.Files
+	}
+	return nil
+}
+
+type FileRequest struct {
+	Hash uint64 `protobuf:"varint,1,opt,name=hash" json:"hash,omitempty"`
+}
+
+func (m *FileRequest) Reset()                    { *m = FileRequest{} }
+func (m *FileRequest) String() string            { return proto.CompactTextString(m) }
+func (*FileRequest) ProtoMessage()               {}
+func (*FileRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{2} }
+
+func (m *FileRequest) GetHash() uint64 {
+	if m != nil {
+		return m.Hash
+	}
+	return 0
+}
+
+type FileChunk struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data" json:"data,omitempty"`
+}
+
+func (m *FileChunk) Reset()                    { *m = FileChunk{} }
+func (m *FileChunk) String() string            { return proto.CompactTextString(m) }
+func (*FileChunk) ProtoMessage()               {}
+func (*FileChunk) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{3} }
+
+func (m *FileChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
 	}
 	return nil
 }
@@ -77,7 +167,10 @@ func (m *FileSetResponse_VersionedSet) GetSet() map[uint64]string {
 func init() {
 	proto.RegisterType((*FileSetRequest)(nil), "rpc.FileSetRequest")
 	proto.RegisterType((*FileSetResponse)(nil), "rpc.FileSetResponse")
+	proto.RegisterType((*FileSetResponse_File)(nil), "rpc.FileSetResponse.File")
 	proto.RegisterType((*FileSetResponse_VersionedSet)(nil), "rpc.FileSetResponse.VersionedSet")
+	proto.RegisterType((*FileRequest)(nil), "rpc.FileRequest")
+	proto.RegisterType((*FileChunk)(nil), "rpc.FileChunk")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -92,6 +185,7 @@ const _ = grpc.SupportPackageIsVersion4
 
 type FileSetClient interface {
 	Get(ctx context.Context, in *FileSetRequest, opts ...grpc.CallOption) (*FileSetResponse, error)
+	Download(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (FileSet_DownloadClient, error)
 }
 
 type fileSetClient struct {
@@ -111,10 +205,43 @@ func (c *fileSetClient) Get(ctx context.Context, in *FileSetRequest, opts ...grp
 	return out, nil
 }
 
+func (c *fileSetClient) Download(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (FileSet_DownloadClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_FileSet_serviceDesc.Streams[0], c.cc, "/rpc.FileSet/Download", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &fileSetDownloadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type FileSet_DownloadClient interface {
+	Recv() (*FileChunk, error)
+	grpc.ClientStream
+}
+
+type fileSetDownloadClient struct {
+	grpc.ClientStream
+}
+
+func (x *fileSetDownloadClient) Recv() (*FileChunk, error) {
+	m := new(FileChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for FileSet service
 
 type FileSetServer interface {
 	Get(context.Context, *FileSetRequest) (*FileSetResponse, error)
+	Download(*FileRequest, FileSet_DownloadServer) error
 }
 
 func RegisterFileSetServer(s *grpc.Server, srv FileSetServer) {
@@ -139,6 +266,27 @@ func _FileSet_Get_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FileSet_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FileSetServer).Download(m, &fileSetDownloadServer{stream})
+}
+
+type FileSet_DownloadServer interface {
+	Send(*FileChunk) error
+	grpc.ServerStream
+}
+
+type fileSetDownloadServer struct {
+	grpc.ServerStream
+}
+
+func (x *fileSetDownloadServer) Send(m *FileChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _FileSet_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "rpc.FileSet",
 	HandlerType: (*FileSetServer)(nil),
@@ -148,28 +296,41 @@ var _FileSet_serviceDesc = grpc.ServiceDesc{
 			Handler:    _FileSet_Get_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Download",
+			Handler:       _FileSet_Download_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "files.proto",
 }
 
 func init() { proto.RegisterFile("files.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 248 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x84, 0xd1, 0xc1, 0x4a, 0xc3, 0x40,
-	0x10, 0x80, 0x61, 0x36, 0x5b, 0x5b, 0x33, 0xd1, 0x16, 0x46, 0x0f, 0x21, 0x07, 0x89, 0x3d, 0x85,
-	0x22, 0x4b, 0x89, 0x07, 0x45, 0xcf, 0xea, 0xcd, 0x83, 0x01, 0xef, 0x5a, 0x47, 0x09, 0x86, 0xec,
-	0xba, 0x33, 0x29, 0xf4, 0xee, 0x33, 0xf8, 0xbc, 0xd2, 0xa4, 0x96, 0x28, 0x05, 0x6f, 0xbb, 0xf0,
-	0xef, 0xc7, 0xb0, 0x03, 0xd1, 0x6b, 0x59, 0x11, 0x1b, 0xe7, 0xad, 0x58, 0xd4, 0xde, 0x2d, 0xa6,
-	0x29, 0x8c, 0x6f, 0xcb, 0x8a, 0x0a, 0x92, 0x07, 0xfa, 0x68, 0x88, 0x05, 0xc7, 0x30, 0x7c, 0xf3,
-	0xb6, 0x71, 0x1c, 0xab, 0x54, 0x67, 0xe1, 0xf4, 0x2b, 0x80, 0xc9, 0x36, 0x61, 0x67, 0x6b, 0x26,
-	0x3c, 0x83, 0x01, 0x93, 0x74, 0x45, 0x94, 0x9f, 0x18, 0xef, 0x16, 0xe6, 0x4f, 0x63, 0x0a, 0x12,
-	0xbe, 0xa9, 0xc5, 0xaf, 0x92, 0x4f, 0x05, 0x07, 0x8f, 0xe4, 0xb9, 0xb4, 0x35, 0xbd, 0x14, 0x24,
-	0x38, 0x81, 0xd1, 0xb2, 0xbb, 0xc7, 0x2a, 0x55, 0xd9, 0x00, 0x2f, 0x40, 0x33, 0x49, 0x1c, 0xb4,
-	0xdc, 0x6c, 0x27, 0xd7, 0x07, 0xd6, 0x76, 0x47, 0xcf, 0x60, 0xff, 0xe7, 0x8c, 0x11, 0xe8, 0x77,
-	0x5a, 0x6d, 0xc4, 0x43, 0xd8, 0x5b, 0x3e, 0x55, 0x0d, 0xc5, 0x41, 0xaa, 0xb2, 0xf0, 0x2a, 0xb8,
-	0x54, 0xc9, 0x3d, 0x84, 0xdb, 0x99, 0xfa, 0x71, 0x88, 0xf3, 0x7e, 0x1c, 0xe5, 0xa7, 0xff, 0x0e,
-	0xb0, 0xf6, 0xf2, 0x6b, 0x18, 0x6d, 0x1a, 0x9c, 0x83, 0xbe, 0x23, 0xc1, 0xa3, 0xdf, 0x0f, 0xdb,
-	0xff, 0x4c, 0x8e, 0x77, 0x69, 0xcf, 0xc3, 0x76, 0x07, 0xe7, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x07, 0x9a, 0xe6, 0x45, 0x92, 0x01, 0x00, 0x00,
+	// 361 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x92, 0xcd, 0x6e, 0xe2, 0x30,
+	0x14, 0x85, 0x65, 0x9c, 0x00, 0xb9, 0xe1, 0x4f, 0x9e, 0x59, 0x98, 0x8c, 0x34, 0xca, 0x64, 0x95,
+	0x05, 0x8a, 0x10, 0xb3, 0x19, 0xcd, 0x2c, 0xa7, 0x2d, 0x52, 0x17, 0x5d, 0x14, 0xa9, 0xeb, 0xa6,
+	0xe4, 0x16, 0x22, 0xd2, 0x38, 0x8d, 0x1d, 0x10, 0x4f, 0xd3, 0x07, 0xe8, 0x4b, 0x56, 0x36, 0x90,
+	0x36, 0x15, 0x52, 0x97, 0x27, 0xe7, 0xf8, 0xbb, 0xe7, 0x3a, 0x06, 0xf7, 0x31, 0xcd, 0x50, 0x46,
+	0x45, 0x29, 0x94, 0x60, 0xb4, 0x2c, 0x96, 0x81, 0x0f, 0x83, 0xab, 0x34, 0xc3, 0x05, 0xaa, 0x5b,
+	0x7c, 0xae, 0x50, 0x2a, 0x36, 0x80, 0xf6, 0xaa, 0x14, 0x55, 0x21, 0x39, 0xf1, 0x69, 0xe8, 0x04,
+	0x2f, 0x14, 0x86, 0x75, 0x44, 0x16, 0x22, 0x97, 0xc8, 0x26, 0x60, 0x49, 0x54, 0x87, 0x84, 0x3b,
+	0xfb, 0x19, 0x95, 0xc5, 0x32, 0xfa, 0x94, 0x89, 0x16, 0xa8, 0xe4, 0x65, 0xae, 0xca, 0xbd, 0x77,
+	0x0f, 0x96, 0x36, 0x59, 0x0f, 0xac, 0x75, 0x2c, 0xd7, 0x9c, 0xf8, 0x24, 0xb4, 0xf4, 0x9c, 0x24,
+	0x5d, 0xa1, 0x54, 0xbc, 0xe5, 0x93, 0xd0, 0xd1, 0xee, 0x93, 0x48, 0x90, 0x53, 0x9f, 0x84, 0x7d,
+	0xd6, 0x07, 0x5b, 0xec, 0x72, 0x2c, 0xb9, 0x65, 0xcc, 0x3e, 0xd8, 0xa6, 0x14, 0xb7, 0x8d, 0xd4,
+	0x67, 0x31, 0x43, 0x85, 0xbc, 0xad, 0xb5, 0xf7, 0x4a, 0xa0, 0x77, 0x87, 0xa5, 0x4c, 0x45, 0x8e,
+	0xc9, 0x02, 0x15, 0x1b, 0x42, 0x67, 0x7b, 0xd0, 0xc7, 0x69, 0xff, 0xc0, 0x36, 0xbb, 0x73, 0x6a,
+	0x2a, 0x4f, 0xce, 0x56, 0xfe, 0x88, 0x30, 0xe6, 0x71, 0x81, 0x39, 0xc0, 0xbb, 0x62, 0x2e, 0xd0,
+	0x0d, 0xee, 0x0d, 0xd7, 0x61, 0x21, 0xd8, 0xdb, 0x38, 0xab, 0xd0, 0x2c, 0xe1, 0xce, 0xc6, 0x67,
+	0xb9, 0x5a, 0xff, 0x6d, 0xfd, 0x21, 0xd7, 0x56, 0xb7, 0x35, 0xa2, 0xde, 0x0d, 0x38, 0xf5, 0xe5,
+	0x34, 0x69, 0xd3, 0x26, 0xed, 0xd7, 0x97, 0x2d, 0x35, 0x35, 0xf8, 0x01, 0xae, 0xce, 0x9c, 0x7e,
+	0x60, 0xe3, 0x9a, 0x83, 0x31, 0x38, 0xda, 0xfc, 0xbf, 0xae, 0xf2, 0x8d, 0xb6, 0x92, 0x58, 0xc5,
+	0xc6, 0xea, 0xcd, 0x36, 0xd0, 0x39, 0xb2, 0xd9, 0x14, 0xe8, 0x1c, 0x15, 0xfb, 0xd6, 0x1c, 0x68,
+	0x78, 0xde, 0xf7, 0x73, 0x2d, 0x58, 0x04, 0xdd, 0x0b, 0xb1, 0xcb, 0x33, 0x11, 0x27, 0x6c, 0x54,
+	0x27, 0x4e, 0x67, 0x06, 0xf5, 0x17, 0x33, 0x78, 0x4a, 0x1e, 0xda, 0xe6, 0xd1, 0xfd, 0x7e, 0x1b,
+	0x00, 0x08, 0x6f, 0xd4, 0x38, 0x83, 0x02, 0x00, 0x00,
 }
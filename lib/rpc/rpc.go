@@ -1,3 +1,3 @@
 package rpc
 
-//go:generate protoc --go_out=plugins=grpc:. event.proto files.proto
+//go:generate protoc --go_out=plugins=grpc:. event.proto files.proto admin.proto
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+//Role is an administrator's access level
+type Role int
+
+//Roles
+const (
+	RoleNone     Role = iota
+	RoleReadOnly      //can view sets, clients, presence and status
+	RoleOperator      //can also reload and manage clients
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleReadOnly:
+		return "readonly"
+	case RoleOperator:
+		return "operator"
+	}
+	return "none"
+}
+
+//UnmarshalJSON satisfies json.Unmarshaler
+func (r *Role) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	switch s {
+	case "readonly":
+		*r = RoleReadOnly
+	case "operator":
+		*r = RoleOperator
+	default:
+		return fmt.Errorf("invalid role: %s", s)
+	}
+	return nil
+}
+
+//Admin is an administrator of the admin API, authenticated by token or client certificate common name
+type Admin struct {
+	Name     string `json:"name"`
+	Token    string `json:"token"`
+	Identity string `json:"identity"`
+	Role     Role   `json:"role"`
+}
+
+//loopbackAdmin is used for loopback requests when loopback access is enabled
+var loopbackAdmin = &Admin{Name: "loopback", Role: RoleOperator}
+
+//AdminAuth authenticates and authorizes admin API requests, audit logging every request.
+//If no admins are configured, every request is denied unless loopback access is enabled,
+//in which case loopback requests are allowed, as an operator
+type AdminAuth struct {
+	admins   []*Admin
+	loopback bool
+	audit    *log.Logger
+}
+
+//NewAdminAuth returns a new AdminAuth with the admins in the JSON file at path.
+//If path is empty, loopback requests are allowed without credentials if loopback is true, and all requests are denied otherwise.
+//If auditPath is not empty, the audit log is appended to it instead of the standard log
+func NewAdminAuth(path, auditPath string, loopback bool) (*AdminAuth, error) {
+	a := &AdminAuth{loopback: loopback && path == "", audit: log.New(os.Stderr, "Audit: ", log.LstdFlags)}
+
+	if auditPath != "" {
+		f, err := os.OpenFile(auditPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening audit log: %v", err)
+		}
+		a.audit = log.New(f, "", log.LstdFlags)
+	}
+
+	if path == "" {
+		return a, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening admins: %v", err)
+	}
+	defer f.Close()
+
+	var admins struct {
+		Admins []*Admin `json:"admins"`
+	}
+	if err = json.NewDecoder(f).Decode(&admins); err != nil {
+		return nil, fmt.Errorf("Error decoding admins: %v", err)
+	}
+	for _, admin := range admins.Admins {
+		if admin.Name == "" || (admin.Token == "" && admin.Identity == "") || admin.Role == RoleNone {
+			return nil, fmt.Errorf("Error decoding admins: admins must have a name, a token or identity, and a role")
+		}
+	}
+	a.admins = admins.Admins
+
+	return a, nil
+}
+
+//authenticate returns the Admin with the given client certificate common name or token,
+//or nil if none match. If loopback access is enabled, loopback addresses are authenticated
+func (a *AdminAuth) authenticate(identity, token string, addr net.IP) *Admin {
+	if a.loopback {
+		if addr != nil && addr.IsLoopback() {
+			return loopbackAdmin
+		}
+		return nil
+	}
+	for _, admin := range a.admins {
+		if identity != "" && admin.Identity == identity {
+			return admin
+		}
+		if token != "" && admin.Token != "" && subtle.ConstantTimeCompare([]byte(admin.Token), []byte(token)) == 1 {
+			return admin
+		}
+	}
+	return nil
+}
+
+//log writes an audit log entry
+func (a *AdminAuth) log(admin *Admin, remote, action, result string) {
+	name, role := "-", RoleNone
+	if admin != nil {
+		name, role = admin.Name, admin.Role
+	}
+	a.audit.Printf("Admin: %s, Role: %s, Remote: %s, Action: %s, Result: %s\n", name, role, remote, action, result)
+}
+
+//statusWriter records the status code written to an http.ResponseWriter
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+//Handler returns an http.Handler that only passes requests from admins with at least the given role on to next.
+//Admins are authenticated with a verified client certificate or an "Authorization: Bearer <token>" header
+func (a *AdminAuth) Handler(role Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var identity, token string
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			identity = r.TLS.VerifiedChains[0][0].Subject.CommonName
+		}
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		action := r.Method + " " + r.URL.RequestURI()
+
+		admin := a.authenticate(identity, token, net.ParseIP(host))
+		if admin == nil {
+			a.log(nil, r.RemoteAddr, action, "unauthenticated")
+			writeJSONError(w, http.StatusUnauthorized)
+			return
+		}
+		if admin.Role < role {
+			a.log(admin, r.RemoteAddr, action, "forbidden")
+			writeJSONError(w, http.StatusForbidden)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		a.log(admin, r.RemoteAddr, action, fmt.Sprintf("%d %s", sw.code, http.StatusText(sw.code)))
+	})
+}
+
+//UnaryInterceptor returns a grpc.UnaryServerInterceptor that only allows admins with at least the role
+//given in roles for the method called. Methods starting with prefix that aren't in roles are denied,
+//and other methods are allowed without authentication.
+//Admins are authenticated with a verified client certificate or "token" metadata
+func (a *AdminAuth) UnaryInterceptor(prefix string, roles map[string]Role) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		role, ok := roles[info.FullMethod]
+		if !ok {
+			if strings.HasPrefix(info.FullMethod, prefix) {
+				a.log(nil, "-", info.FullMethod, "no role configured")
+				return nil, grpc.Errorf(codes.PermissionDenied, "no role configured for %s", info.FullMethod)
+			}
+			return handler(ctx, req)
+		}
+
+		var identity, token, remote string
+		var addr net.IP
+		if p, ok := peer.FromContext(ctx); ok {
+			remote = p.Addr.String()
+			if tcp, ok := p.Addr.(*net.TCPAddr); ok {
+				addr = tcp.IP
+			}
+			if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+				identity = tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+			}
+		}
+		if md, ok := metadata.FromContext(ctx); ok && len(md["token"]) > 0 {
+			token = md["token"][0]
+		}
+
+		admin := a.authenticate(identity, token, addr)
+		if admin == nil {
+			a.log(nil, remote, info.FullMethod, "unauthenticated")
+			return nil, grpc.Errorf(codes.Unauthenticated, "admin authentication required")
+		}
+		if admin.Role < role {
+			a.log(admin, remote, info.FullMethod, "forbidden")
+			return nil, grpc.Errorf(codes.PermissionDenied, "%s role required", role)
+		}
+
+		resp, err := handler(ctx, req)
+		result := "OK"
+		if err != nil {
+			result = err.Error()
+		}
+		a.log(admin, remote, info.FullMethod, result)
+		return resp, err
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/korylprince/jettison/lib/rpc"
+)
+
+func TestAdminRolesComplete(t *testing.T) {
+	s := grpc.NewServer()
+	rpc.RegisterAdminServer(s, &AdminServer{})
+	for _, m := range s.GetServiceInfo()["rpc.Admin"].Methods {
+		if _, ok := AdminRoles[adminServicePrefix+m.Name]; !ok {
+			t.Errorf("%s: no role configured", m.Name)
+		}
+	}
+}
+
+func TestUnaryInterceptorFailsClosed(t *testing.T) {
+	a := &AdminAuth{audit: log.New(ioutil.Discard, "", 0)}
+	interceptor := a.UnaryInterceptor(adminServicePrefix, map[string]Role{adminServicePrefix + "Known": RoleReadOnly})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "called", nil }
+
+	tests := []struct {
+		method string
+		code   codes.Code
+	}{
+		{adminServicePrefix + "Unknown", codes.PermissionDenied},
+		{adminServicePrefix + "Known", codes.Unauthenticated},
+		{"/rpc.FileSet/Get", codes.OK},
+	}
+
+	for _, test := range tests {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: test.method}, handler)
+		if code := grpc.Code(err); code != test.code {
+			t.Errorf("%s: expected %v, got %v", test.method, test.code, code)
+		}
+	}
+}
+
+func TestAdminAuthenticate(t *testing.T) {
+	admins := []*Admin{{Name: "ops", Token: "operator-token", Role: RoleOperator}, {Name: "cert", Identity: "admin.example.com", Role: RoleReadOnly}}
+	loopback, remote := net.ParseIP("127.0.0.1"), net.ParseIP("192.0.2.1")
+
+	tests := []struct {
+		name     string
+		auth     *AdminAuth
+		identity string
+		token    string
+		addr     net.IP
+		admin    string
+	}{
+		{"no admins loopback", &AdminAuth{}, "", "", loopback, ""},
+		{"no admins remote", &AdminAuth{}, "", "", remote, ""},
+		{"loopback enabled", &AdminAuth{loopback: true}, "", "", loopback, "loopback"},
+		{"loopback enabled remote", &AdminAuth{loopback: true}, "", "", remote, ""},
+		{"token", &AdminAuth{admins: admins}, "", "operator-token", remote, "ops"},
+		{"wrong token", &AdminAuth{admins: admins}, "", "wrong", loopback, ""},
+		{"identity", &AdminAuth{admins: admins}, "admin.example.com", "", remote, "cert"},
+		{"no credentials", &AdminAuth{admins: admins}, "", "", loopback, ""},
+	}
+
+	for _, test := range tests {
+		name := ""
+		if admin := test.auth.authenticate(test.identity, test.token, test.addr); admin != nil {
+			name = admin.Name
+		}
+		if name != test.admin {
+			t.Errorf("%s: expected %q, got %q", test.name, test.admin, name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/korylprince/jettison/lib/file"
+)
+
+//GroupDiff represents the changes to a group between two versions.
+//OldVersion is 0 if the group was added, and NewVersion is 0 if the group was removed
+type GroupDiff struct {
+	OldVersion uint64
+	NewVersion uint64
+	Added      []string `json:",omitempty"`
+	Removed    []string `json:",omitempty"`
+	Modified   []string `json:",omitempty"` //paths whose content or metadata changed
+}
+
+//Diff is the changes between two sets of VersionedSets, map[group]*GroupDiff.
+//Only groups that changed versions are included
+type Diff map[string]*GroupDiff
+
+//ReloadResult is the result of checking a definition
+type ReloadResult struct {
+	DryRun   bool `json:",omitempty"` //true if the result was only previewed and not published
+	Groups   Diff
+	Warnings []string          `json:",omitempty"`
+	Errors   map[string]string `json:",omitempty"` //group:error for groups that failed to walk and kept their published version
+
+	NotifyFailures int `json:",omitempty"` //number of notifications of the changes that couldn't be sent
+}
+
+//diffSets returns the changes from one VersionedSet to another, either of which may be nil
+func diffSets(from, to *file.VersionedSet) *GroupDiff {
+	d := new(GroupDiff)
+	var oldSet, newSet file.Set
+	if from != nil {
+		d.OldVersion, oldSet = from.Version, from.Set
+	}
+	if to != nil {
+		d.NewVersion, newSet = to.Version, to.Set
+	}
+
+	for path, entry := range newSet {
+		if o, ok := oldSet[path]; !ok {
+			d.Added = append(d.Added, path)
+		} else if *o != *entry {
+			d.Modified = append(d.Modified, path)
+		}
+	}
+	for path := range oldSet {
+		if _, ok := newSet[path]; !ok {
+			d.Removed = append(d.Removed, path)
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Modified)
+
+	return d
+}
+
+//diffVersionedSets returns the Diff of the groups that changed versions between from and to
+func diffVersionedSets(from, to map[string]*file.VersionedSet) Diff {
+	diff := make(Diff)
+	for group, vs := range to {
+		if old, ok := from[group]; !ok || old.Version != vs.Version {
+			diff[group] = diffSets(old, vs)
+		}
+	}
+	for group, old := range from {
+		if _, ok := to[group]; !ok {
+			diff[group] = diffSets(old, nil)
+		}
+	}
+	return diff
+}
+
+//Versions returns the new versions of groups that weren't removed, map[group]version
+func (d Diff) Versions() map[string]uint64 {
+	versions := make(map[string]uint64)
+	for group, gd := range d {
+		if gd.NewVersion != 0 {
+			versions[group] = gd.NewVersion
+		}
+	}
+	return versions
+}
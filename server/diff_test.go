@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/korylprince/jettison/lib/file"
+)
+
+func TestDiffSets(t *testing.T) {
+	vs := func(s file.Set) *file.VersionedSet { return file.NewVersionedSet(s) }
+	base := file.Set{
+		"/a": {Hash: 1},
+		"/b": {Hash: 2},
+		"/c": {Hash: 3, Mode: 0644},
+	}
+
+	tests := []struct {
+		name                     string
+		from, to                 *file.VersionedSet
+		added, removed, modified []string
+		oldVersion, newVersion   bool
+	}{
+		{"added group", nil, vs(base), []string{"/a", "/b", "/c"}, nil, nil, false, true},
+		{"removed group", vs(base), nil, nil, []string{"/a", "/b", "/c"}, nil, true, false},
+		{"unchanged", vs(base), vs(file.Set{"/a": {Hash: 1}, "/b": {Hash: 2}, "/c": {Hash: 3, Mode: 0644}}), nil, nil, nil, true, true},
+		{"changed", vs(base), vs(file.Set{
+			"/a": {Hash: 10},            //content
+			"/c": {Hash: 3, Mode: 0600}, //metadata
+			"/d": {Hash: 4},
+		}), []string{"/d"}, []string{"/b"}, []string{"/a", "/c"}, true, true},
+	}
+
+	for _, test := range tests {
+		d := diffSets(test.from, test.to)
+		if !reflect.DeepEqual(d.Added, test.added) {
+			t.Errorf("%s: Added: expected %v, got %v", test.name, test.added, d.Added)
+		}
+		if !reflect.DeepEqual(d.Removed, test.removed) {
+			t.Errorf("%s: Removed: expected %v, got %v", test.name, test.removed, d.Removed)
+		}
+		if !reflect.DeepEqual(d.Modified, test.modified) {
+			t.Errorf("%s: Modified: expected %v, got %v", test.name, test.modified, d.Modified)
+		}
+		if (d.OldVersion != 0) != test.oldVersion || (test.from != nil && d.OldVersion != test.from.Version) {
+			t.Errorf("%s: unexpected OldVersion %d", test.name, d.OldVersion)
+		}
+		if (d.NewVersion != 0) != test.newVersion || (test.to != nil && d.NewVersion != test.to.Version) {
+			t.Errorf("%s: unexpected NewVersion %d", test.name, d.NewVersion)
+		}
+	}
+}
+
+func TestDiffVersionedSets(t *testing.T) {
+	a := file.NewVersionedSet(file.Set{"/a": {Hash: 1}})
+	b := file.NewVersionedSet(file.Set{"/b": {Hash: 2}})
+	c := file.NewVersionedSet(file.Set{"/c": {Hash: 3}})
+
+	d := diffVersionedSets(
+		map[string]*file.VersionedSet{"same": a, "changed": a, "removed": b},
+		map[string]*file.VersionedSet{"same": a, "changed": c, "added": b},
+	)
+
+	if _, ok := d["same"]; ok || len(d) != 3 {
+		t.Fatalf("expected changed, removed and added groups, got %v", d)
+	}
+	if v := d.Versions(); !reflect.DeepEqual(v, map[string]uint64{"changed": c.Version, "added": b.Version}) {
+		t.Errorf("Versions: unexpected %v", v)
+	}
+}
@@ -2,16 +2,45 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/korylprince/jettison/lib/file"
 )
 
 //Config stores configuration from the environment
 type Config struct {
-	HTTPListenAddr string
+	HTTPListenAddr string //file downloads and the admin HTTP API. Disabled if set to empty or "off"
 	RPCListenAddr  string
 	DefinitionPath string
 	CachePath      string
+	HashAlgorithm  string //sha256 or blake2b, or empty to disable digests
+	ReportPath     string //reports are kept in memory if empty
+
+	ReportInterval time.Duration //in seconds, used for clients that don't send their interval
+	StaleIntervals int           //number of missed report intervals before a client is stale
+
+	TolerantReload bool //publish groups that walk cleanly and keep the published version of groups that fail
+
+	DisableWatch bool          //disable reloading when the definition or origins change
+	WatchDelay   time.Duration //in seconds, time without changes before reloading
+
+	TLSCert     string //PEM certificate path. TLS is disabled if empty
+	TLSKey      string //PEM key path
+	TLSClientCA string //PEM CA path. If set, client certificates signed by it identify clients and admins
+
+	TLSRequireClientCert bool //reject connections without a client certificate signed by TLSClientCA, even from token authenticated clients
+
+	PolicyPath string //JSON policy path. If empty, all clients can access all groups
+
+	HistoryPath  string //published versions database path. History and rollback are disabled if empty
+	SnapshotPath string //directory for snapshots of files in published versions
+	HistoryLimit int    //number of versions kept per group
+
+	AdminPath     string //JSON admins path. If empty, the admin API is disabled unless AdminLoopback is set
+	AdminLoopback bool   //if AdminPath is empty, allow operator access to the admin API from loopback addresses without credentials
+	AuditPath     string //admin API audit log path. If empty, the audit log is written to the standard log
 }
 
 //ParseEnv parses a Config from the environment, returning an error if one occurred
@@ -21,18 +50,55 @@ func ParseEnv() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Error reading configuration from environment: %v", err)
 	}
-	if config.HTTPListenAddr == "" {
+	if _, ok := os.LookupEnv("JETTISON_HTTPLISTENADDR"); !ok {
 		config.HTTPListenAddr = ":50080"
+	} else if config.HTTPListenAddr == "off" {
+		config.HTTPListenAddr = ""
 	}
 	if config.RPCListenAddr == "" {
 		config.RPCListenAddr = ":50081"
 	}
+	if config.ReportInterval == 0 {
+		config.ReportInterval = 60
+	}
+	if config.StaleIntervals == 0 {
+		config.StaleIntervals = 3
+	}
+	if config.WatchDelay == 0 {
+		config.WatchDelay = 2
+	}
+	if config.HistoryLimit == 0 {
+		config.HistoryLimit = 10
+	}
 	if config.DefinitionPath == "" {
 		return nil, fmt.Errorf("JETTISON_DEFINITIONPATH must be configured")
 	}
 	if config.CachePath == "" {
 		return nil, fmt.Errorf("JETTISON_CACHEPATH must be configured")
 	}
+	if (config.TLSCert == "") != (config.TLSKey == "") {
+		return nil, fmt.Errorf("JETTISON_TLSCERT and JETTISON_TLSKEY must be configured together")
+	}
+	if config.TLSClientCA != "" && config.TLSCert == "" {
+		return nil, fmt.Errorf("JETTISON_TLSCERT must be configured to use JETTISON_TLSCLIENTCA")
+	}
+	if config.TLSRequireClientCert && config.TLSClientCA == "" {
+		return nil, fmt.Errorf("JETTISON_TLSCLIENTCA must be configured to use JETTISON_TLSREQUIRECLIENTCERT")
+	}
+	if config.AdminLoopback && config.AdminPath != "" {
+		return nil, fmt.Errorf("JETTISON_ADMINLOOPBACK can't be used with JETTISON_ADMINPATH")
+	}
+	if config.HistoryLimit < 1 {
+		return nil, fmt.Errorf("JETTISON_HISTORYLIMIT must be at least 1")
+	}
+	if config.HistoryPath != "" && config.SnapshotPath == "" {
+		return nil, fmt.Errorf("JETTISON_SNAPSHOTPATH must be configured to use JETTISON_HISTORYPATH")
+	}
+	if config.HashAlgorithm != "" {
+		if _, err = file.NewHasher(config.HashAlgorithm); err != nil {
+			return nil, fmt.Errorf("JETTISON_HASHALGORITHM invalid: %v", err)
+		}
+	}
 
 	return config, nil
 }
@@ -8,43 +8,72 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 
+	"github.com/gorilla/mux"
 	"github.com/korylprince/jettison/lib/cache"
 	"github.com/korylprince/jettison/lib/file"
 )
 
 //FileService is a thread-safe access to file sets
 type FileService struct {
-	cache cache.Cache
-	sets  map[string]*file.VersionedSet //group:VersionedSet
-	mu    *sync.RWMutex
+	cache   cache.Cache
+	hasher  file.Hasher                   //nil if digests are disabled
+	sets    map[string]*file.VersionedSet //group:VersionedSet
+	origins map[uint64]string             //hash:origin path
+	groups  map[uint64][]string           //hash:groups containing the file
+	errors  map[string]string             //group:error for groups that failed to walk in the last check
+	walked  map[string]uint64             //group:version of the group's origins in the last successful walk
+	pins    map[string]*Pin               //group:Pin for rolled back groups
+	mu      *sync.RWMutex
+
+	tolerant bool     //if true, groups that fail to walk keep their published version
+	history  *History //nil if history is disabled
 }
 
-//FilesFromDefinition returns a new FileService with the given definition and cache paths or an error if one occurred
-func FilesFromDefinition(defPath, cachePath string) (*FileService, error) {
+//ErrorHistoryDisabled signals that history isn't configured
+var ErrorHistoryDisabled = fmt.Errorf("history disabled")
+
+//ErrorGroupNotFound signals that the given group isn't published
+var ErrorGroupNotFound = fmt.Errorf("group not found")
+
+//FilesFromDefinition returns a new FileService with the given definition and cache paths or an error if one occurred.
+//If h is not nil, it will be used to compute file digests.
+//If tolerant is true, groups that fail to walk are skipped, or keep their published version on later checks.
+//If history is not nil, published versions are recorded to it and groups can be rolled back
+func FilesFromDefinition(defPath, cachePath string, h file.Hasher, tolerant bool, history *History) (*FileService, error) {
 	c, err := cache.NewBoltCache(cachePath)
 	if err != nil {
 		return nil, err
 	}
-	f := &FileService{cache: c, mu: new(sync.RWMutex)}
-	_, err = f.CheckDefinition(defPath)
+	f := &FileService{cache: c, hasher: h, mu: new(sync.RWMutex), tolerant: tolerant, history: history, pins: make(map[string]*Pin)}
+	if history != nil {
+		if f.pins, err = history.Pins(); err != nil {
+			return nil, fmt.Errorf("Error reading pins: %v", err)
+		}
+	}
+	_, err = f.CheckDefinition(defPath, false)
 	return f, err
 }
 
-//Origin returns the origin path for the given path, if ok is true
+//Origin returns the origin path for the given hash, if ok is true
 func (f *FileService) Origin(hash uint64) (path string, ok bool) {
 	f.mu.RLock()
-	if _, ok = f.sets["_origin"]; ok {
-		path, ok = f.sets["_origin"].Set[hash]
-	} else {
-		panic(fmt.Errorf("f.sets[\"_origin\"] accessed but doesn't exist"))
-	}
+	path, ok = f.origins[hash]
 	f.mu.RUnlock()
 	return path, ok
 }
 
+//Groups returns the groups containing the file with the given hash
+func (f *FileService) Groups(hash uint64) []string {
+	f.mu.RLock()
+	groups := f.groups[hash]
+	f.mu.RUnlock()
+	return groups
+}
+
 //Sets returns VersionedSets for the given groups. The caller should not modify the result
 func (f *FileService) Sets(groups ...string) map[string]*file.VersionedSet {
 	sets := make(map[string]*file.VersionedSet)
@@ -58,37 +87,310 @@ func (f *FileService) Sets(groups ...string) map[string]*file.VersionedSet {
 	return sets
 }
 
+//AllSets returns the VersionedSets for all groups. The caller should not modify the result
+func (f *FileService) AllSets() map[string]*file.VersionedSet {
+	sets := make(map[string]*file.VersionedSet)
+	f.mu.RLock()
+	for group, vs := range f.sets {
+		sets[group] = vs
+	}
+	f.mu.RUnlock()
+	return sets
+}
+
+//hashGroups returns a mapping of hashes to the groups containing them
+func hashGroups(mapped map[string]*file.VersionedSet) map[uint64][]string {
+	groups := make(map[uint64][]string)
+	for group, vs := range mapped {
+		seen := make(map[uint64]struct{})
+		for _, entry := range vs.Set {
+			if _, ok := seen[entry.Hash]; !ok {
+				seen[entry.Hash] = struct{}{}
+				groups[entry.Hash] = append(groups[entry.Hash], group)
+			}
+		}
+	}
+	return groups
+}
+
+//unchanged returns true if the file at path still has the given hash,
+//using the hash cache if the file hasn't been modified since it was hashed
+func (f *FileService) unchanged(path string, hash uint64) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if e, err := f.cache.Get(path); err == nil && e.Hash == hash &&
+		!info.ModTime().After(e.ModTime) && (e.Size < 0 || e.Size == info.Size()) {
+		return true
+	}
+	h, err := file.Hash(path)
+	return err == nil && h == hash
+}
+
+//resolveOrigins adds origins for the files in vs that are missing from origins,
+//using snapshots or the published origins. Published origins that no longer have the same content are skipped,
+//so they aren't served under the wrong hash. The number of files left without an origin is returned.
+//Origins may be hashed, so f.mu shouldn't be held
+func (f *FileService) resolveOrigins(published, origins map[uint64]string, vs *file.VersionedSet) (missing int) {
+	for _, entry := range vs.Set {
+		if _, ok := origins[entry.Hash]; ok {
+			continue
+		}
+		if f.history != nil {
+			if path, ok := f.history.Snapshot(entry.Hash); ok {
+				origins[entry.Hash] = path
+				continue
+			}
+		}
+		if path, ok := published[entry.Hash]; ok && f.unchanged(path, entry.Hash) {
+			origins[entry.Hash] = path
+			continue
+		}
+		missing++
+	}
+	return missing
+}
+
+//pinnedSet returns the VersionedSet for group's pinned version, using the published sets if it's published
+func (f *FileService) pinnedSet(sets map[string]*file.VersionedSet, group string, p *Pin) (*file.VersionedSet, error) {
+	if vs, ok := sets[group]; ok && vs.Version == p.Version {
+		return vs, nil
+	}
+	entry, err := f.history.Get(group, p.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &file.VersionedSet{Set: entry.Set, Version: entry.Version}, nil
+}
+
 //CheckDefinition causes f to reread the definition and filesystem for changes
-//CheckDefinition returns changed, a map[group]version of any groups that changed versions
+//CheckDefinition returns the Diff of any groups that changed versions and any validation warnings.
+//If f is tolerant, groups that fail to walk keep their published version and their errors are returned instead.
+//Rolled back groups keep their pinned version until their origins change.
+//If dryRun is true, the changes are computed but not published.
+//f.mu is only held while reading and publishing, so calls to CheckDefinition and Rollback must be serialized by the caller
 //CheckDefinition blocks until finished or returns an error if one occurred
-func (f *FileService) CheckDefinition(defPath string) (changed map[string]uint64, err error) {
+func (f *FileService) CheckDefinition(defPath string, dryRun bool) (*ReloadResult, error) {
 	def, err := file.Parse(defPath)
 	if err != nil {
 		return nil, err
 	}
-	all, mapped, err := WalkDefinition(context.Background(), def, f.cache, 10)
+	origins, mapped, failed, err := WalkDefinition(context.Background(), def, f.cache, f.hasher, 10, f.tolerant)
 	if err != nil {
 		return nil, err
 	}
 
-	f.mu.Lock()
+	//copy the published state so origins can be hashed without blocking readers
+	f.mu.RLock()
+	sets, published, prevWalked := f.sets, f.origins, f.walked
+	pins := make(map[string]*Pin, len(f.pins))
+	for group, p := range f.pins {
+		pins[group] = p
+	}
+	f.mu.RUnlock()
 
-	changed = make(map[string]uint64)
-	//check if versior changed
-	for group, new := range mapped {
-		if old, ok := f.sets[group]; ok {
-			if group != "_origin" && new.Version != old.Version {
-				changed[group] = new.Version
+	walked := make(map[string]uint64)
+	for group, vs := range mapped {
+		walked[group] = vs.Version
+	}
+
+	var unpinned []string
+	for group, p := range pins {
+		if _, ok := failed[group]; ok {
+			//the pinned version is kept below
+			continue
+		}
+		vs, ok := mapped[group]
+		if !ok || vs.Version != p.Walked {
+			unpinned = append(unpinned, group)
+			continue
+		}
+		pinned, err := f.pinnedSet(sets, group, p)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading pinned version of group %s: %v", group, err)
+		}
+		mapped[group] = pinned
+		f.resolveOrigins(published, origins, pinned)
+	}
+
+	errors := make(map[string]string)
+	for group, err := range failed {
+		errors[group] = err.Error()
+		if v, ok := prevWalked[group]; ok {
+			walked[group] = v
+		}
+
+		if vs, ok := sets[group]; ok {
+			mapped[group] = vs
+			if missing := f.resolveOrigins(published, origins, vs); missing > 0 {
+				errors[group] = fmt.Sprintf("%s (%d published files changed and are unavailable)", errors[group], missing)
 			}
-		} else {
-			changed[group] = new.Version
 		}
+
+		if !dryRun {
+			log.Printf("FileService: Error walking group %s: %s\n", group, errors[group])
+		}
+	}
+
+	result := &ReloadResult{
+		DryRun:   dryRun,
+		Groups:   diffVersionedSets(sets, mapped),
+		Warnings: validate(def, sets, mapped),
+		Errors:   errors,
+	}
+	if dryRun {
+		return result, nil
 	}
-	mapped["_origin"] = &file.VersionedSet{Set: all}
+
+	f.mu.Lock()
 	f.sets = mapped
+	f.origins = origins
+	f.groups = hashGroups(mapped)
+	f.errors = errors
+	f.walked = walked
+
+	for _, group := range unpinned {
+		delete(f.pins, group)
+		if err := f.history.Unpin(group); err != nil {
+			log.Printf("FileService: Error unpinning group %s: %v\n", group, err)
+		}
+		log.Printf("FileService: Group %s changed since it was rolled back, publishing its origins\n", group)
+	}
+
+	record := make(map[string]*file.VersionedSet)
+	if f.history != nil {
+		for group, gd := range result.Groups {
+			if _, ok := f.pins[group]; !ok && gd.NewVersion != 0 {
+				record[group] = mapped[group]
+			}
+		}
+	}
 
 	f.mu.Unlock()
-	return changed, nil
+
+	//origins and mapped aren't modified once published
+	for group, vs := range record {
+		if err := f.history.Record(group, vs, origins); err != nil {
+			log.Printf("FileService: Error recording history of group %s: %v\n", group, err)
+		}
+	}
+
+	return result, nil
+}
+
+//Rollback publishes the recorded version of group, pinning the group at that version until its origins change,
+//and returns the changes. ErrorHistoryDisabled, ErrorGroupNotFound, or ErrorVersionNotFound is returned if applicable
+func (f *FileService) Rollback(group string, version uint64) (*GroupDiff, error) {
+	if f.history == nil {
+		return nil, ErrorHistoryDisabled
+	}
+
+	entry, err := f.history.Get(group, version)
+	if err != nil {
+		return nil, err
+	}
+	vs := &file.VersionedSet{Set: entry.Set, Version: entry.Version}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	published, ok := f.sets[group]
+	if !ok {
+		return nil, ErrorGroupNotFound
+	}
+
+	//published maps aren't modified, so they're copied
+	origins := make(map[uint64]string, len(f.origins))
+	for hash, path := range f.origins {
+		origins[hash] = path
+	}
+	for _, e := range vs.Set {
+		path, ok := f.history.Snapshot(e.Hash)
+		if !ok {
+			return nil, fmt.Errorf("Error rolling back group %s: missing snapshot %d", group, e.Hash)
+		}
+		origins[e.Hash] = path
+	}
+	sets := make(map[string]*file.VersionedSet, len(f.sets))
+	for g, s := range f.sets {
+		sets[g] = s
+	}
+	sets[group] = vs
+
+	p := &Pin{Version: version, Walked: f.walked[group]}
+	if err = f.history.Pin(group, p); err != nil {
+		return nil, fmt.Errorf("Error pinning group %s: %v", group, err)
+	}
+
+	f.sets = sets
+	f.origins = origins
+	f.groups = hashGroups(sets)
+	f.pins[group] = p
+
+	return diffSets(published, vs), nil
+}
+
+//HistoryVersion is a recorded version of a group
+type HistoryVersion struct {
+	Version   uint64
+	Time      time.Time
+	Files     int
+	Published bool //true if this is the group's published version
+}
+
+//History returns the recorded versions of group, newest first, or ErrorHistoryDisabled if history isn't configured
+func (f *FileService) History(group string) ([]*HistoryVersion, error) {
+	if f.history == nil {
+		return nil, ErrorHistoryDisabled
+	}
+	entries, err := f.history.Entries(group)
+	if err != nil {
+		return nil, err
+	}
+
+	var published uint64
+	if vs, ok := f.Sets(group)[group]; ok {
+		published = vs.Version
+	}
+
+	versions := make([]*HistoryVersion, 0, len(entries))
+	for _, e := range entries {
+		versions = append(versions, &HistoryVersion{Version: e.Version, Time: e.Time, Files: len(e.Set), Published: e.Version == published})
+	}
+	return versions, nil
+}
+
+//Pinned returns the pinned versions of rolled back groups, map[group]version
+func (f *FileService) Pinned() map[string]uint64 {
+	pinned := make(map[string]uint64)
+	f.mu.RLock()
+	for group, p := range f.pins {
+		pinned[group] = p.Version
+	}
+	f.mu.RUnlock()
+	return pinned
+}
+
+//ServeHistory is an http.HandlerFunc, returning the recorded versions of the group in the URL in JSON
+func (f *FileService) ServeHistory(w http.ResponseWriter, r *http.Request) {
+	versions, err := f.History(mux.Vars(r)["group"])
+	if err == ErrorHistoryDisabled {
+		writeJSONError(w, http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Println("FileService: Error getting history:", err)
+		writeJSONError(w, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, versions)
+}
+
+//Errors returns the errors of groups that failed to walk in the last check, map[group]error
+func (f *FileService) Errors() map[string]string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.errors
 }
 
 //Open statisfies http.FileSystem
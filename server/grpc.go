@@ -2,26 +2,51 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/korylprince/jettison/lib/db"
 	"github.com/korylprince/jettison/lib/rpc"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 )
 
 //FileSetServer is a GRPC FileSetService
 type FileSetServer struct {
-	Files *FileService
+	Files  *FileService
+	Policy *Policy
 }
 
-//Get returns FileSets for the groups requested
+//Get returns FileSets for the groups requested that the caller may access
 func (s FileSetServer) Get(ctx context.Context, r *rpc.FileSetRequest) (*rpc.FileSetResponse, error) {
-	sets := s.Files.Sets(r.GetGroups()...)
+	caller := s.Policy.GRPCCaller(ctx)
+	groups, denied := s.Policy.Filter(caller, r.GetGroups())
+	if len(denied) > 0 {
+		LogGRPC(ctx, "FileSetRequest", fmt.Sprintf("Denied Groups: %s to %v", strings.Join(denied, ", "), caller))
+	}
+
+	sets := s.Files.Sets(groups...)
 	var grps sort.StringSlice
 	resp := &rpc.FileSetResponse{Sets: make(map[string]*rpc.FileSetResponse_VersionedSet)}
 	for group, set := range sets {
-		resp.Sets[group] = &rpc.FileSetResponse_VersionedSet{Set: set.Set, Version: set.Version}
+		files := make(map[string]*rpc.FileSetResponse_File, len(set.Set))
+		for path, entry := range set.Set {
+			files[path] = &rpc.FileSetResponse_File{
+				Hash:   entry.Hash,
+				Digest: entry.Digest,
+				Mode:   uint32(entry.Mode),
+				Owner:  entry.Owner,
+				Group:  entry.Group,
+				Delete: string(entry.Delete),
+			}
+		}
+		resp.Sets[group] = &rpc.FileSetResponse_VersionedSet{Files: files, Version: set.Version}
 		grps = append(grps, fmt.Sprintf("%s:%d", group, set.Version))
 	}
 	grps.Sort()
@@ -29,16 +54,71 @@ func (s FileSetServer) Get(ctx context.Context, r *rpc.FileSetRequest) (*rpc.Fil
 	return resp, nil
 }
 
+//chunkSize is the size of FileChunks sent by Download
+const chunkSize = 64 * 1024
+
+//Download streams the content of the file with the requested hash, if the caller may access a group containing it
+func (s FileSetServer) Download(r *rpc.FileRequest, stream rpc.FileSet_DownloadServer) error {
+	if caller := s.Policy.GRPCCaller(stream.Context()); !s.Policy.AllowFile(caller, s.Files, r.GetHash()) {
+		LogGRPC(stream.Context(), "FileRequest", fmt.Sprintf("Hash: %d, Denied to %v", r.GetHash(), caller))
+		return grpc.Errorf(codes.PermissionDenied, "access denied: %d", r.GetHash())
+	}
+
+	path, ok := s.Files.Origin(r.GetHash())
+	if !ok {
+		LogGRPC(stream.Context(), "FileRequest", fmt.Sprintf("Hash: %d, Error: not found", r.GetHash()))
+		return grpc.Errorf(codes.NotFound, "file not found: %d", r.GetHash())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		LogGRPC(stream.Context(), "FileRequest", fmt.Sprintf("Hash: %d, Error: %v", r.GetHash(), err))
+		return grpc.Errorf(codes.Internal, "error opening file: %d", r.GetHash())
+	}
+	defer f.Close()
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if sErr := stream.Send(&rpc.FileChunk{Data: buf[:n]}); sErr != nil {
+				return sErr
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			LogGRPC(stream.Context(), "FileRequest", fmt.Sprintf("Hash: %d, Error: %v", r.GetHash(), err))
+			return grpc.Errorf(codes.Internal, "error reading file: %d", r.GetHash())
+		}
+	}
+
+	LogGRPC(stream.Context(), "FileRequest", fmt.Sprintf("Hash: %d", r.GetHash()))
+	return nil
+}
+
 //EventServer is a GRPC EventService
 type EventServer struct {
-	NotifyService *NotifyService
+	NotifyService   *NotifyService
+	PresenceService *PresenceService
+	Reports         db.Store
+	Policy          *Policy
 }
 
-//Stream registers the stream for the groups included in metadata and saves reports to the database
+//Stream registers the stream for the groups included in metadata that the caller may access,
+//tracks the client's presence, and saves reports to the database
 func (s EventServer) Stream(stream rpc.Events_StreamServer) error {
+	var hardwareAddr string
+	var interval time.Duration
+
 	//register for notifications
 	if md, ok := metadata.FromContext(stream.Context()); ok {
-		if groups, ok := md["groups"]; ok && groups != nil {
+		caller := s.Policy.GRPCCaller(stream.Context())
+		groups, denied := s.Policy.Filter(caller, md["groups"])
+		if len(denied) > 0 {
+			LogGRPC(stream.Context(), "Register", fmt.Sprintf("Denied Groups: %s to %v", strings.Join(denied, ", "), caller))
+		}
+		if len(groups) > 0 {
 			s.NotifyService.Register(stream, groups...)
 			LogGRPC(stream.Context(), "Register", fmt.Sprintf("Groups: %s", strings.Join(groups, ", ")))
 			defer func() {
@@ -46,20 +126,219 @@ func (s EventServer) Stream(stream rpc.Events_StreamServer) error {
 				LogGRPC(stream.Context(), "Unregister", fmt.Sprintf("Groups: %s", strings.Join(groups, ", ")))
 			}()
 		}
+		if addr, ok := md["hardware_addr"]; ok && len(addr) > 0 {
+			hardwareAddr = addr[0]
+		}
+		if i, ok := md["report_interval"]; ok && len(i) > 0 {
+			if secs, err := strconv.Atoi(i[0]); err == nil {
+				interval = time.Duration(secs) * time.Second
+			}
+		}
 	}
+
+	//clients without hardware_addr metadata are connected on their first report
+	if hardwareAddr != "" {
+		s.PresenceService.Connect(hardwareAddr, interval)
+	}
+	defer func() {
+		if hardwareAddr != "" {
+			s.PresenceService.Disconnect(hardwareAddr)
+		}
+	}()
+
 	for {
 		rpt, err := stream.Recv()
 		if err != nil {
 			LogGRPC(stream.Context(), "Report", fmt.Sprintf("Error: %v", err))
 			return err
 		}
-		Report(rpt)
-		LogGRPC(stream.Context(), "Report", fmt.Sprintf("HardwareAddr: %s, Location: %s, Version: %v",
-			rpt.GetHardwareAddr(), rpt.GetLocation(), rpt.GetVersion()))
+		if hardwareAddr == "" {
+			hardwareAddr = rpt.GetHardwareAddr()
+			s.PresenceService.Connect(hardwareAddr, interval)
+		}
+		s.PresenceService.Report(hardwareAddr)
+		LogGRPC(stream.Context(), "Report", fmt.Sprintf("HardwareAddr: %s, Location: %s, Version: %v, Drift: %v",
+			rpt.GetHardwareAddr(), rpt.GetLocation(), rpt.GetVersion(), rpt.GetDrift()))
+		if err = s.Report(rpt); err != nil {
+			LogGRPC(stream.Context(), "Report", fmt.Sprintf("Error saving report: %v", err))
+		}
 	}
 }
 
 //Report saves the report to the database
-func Report(rpt *rpc.Report) {
-	//TODO
+func (s EventServer) Report(rpt *rpc.Report) error {
+	return s.Reports.Put(&db.Report{
+		HardwareAddr: rpt.GetHardwareAddr(),
+		Location:     rpt.GetLocation(),
+		Version:      rpt.GetVersion(),
+		Drift:        rpt.GetDrift(),
+		Time:         time.Now(),
+	})
+}
+
+//adminServicePrefix is the prefix of every AdminServer method
+const adminServicePrefix = "/rpc.Admin/"
+
+//AdminRoles is the minimum Role required for each AdminServer method.
+//Methods missing from AdminRoles are denied
+var AdminRoles = map[string]Role{
+	adminServicePrefix + "Clients":      RoleReadOnly,
+	adminServicePrefix + "Presence":     RoleReadOnly,
+	adminServicePrefix + "DeleteClient": RoleOperator,
+	adminServicePrefix + "Reload":       RoleOperator,
+	adminServicePrefix + "History":      RoleReadOnly,
+	adminServicePrefix + "Rollback":     RoleOperator,
+}
+
+//AdminServer is a GRPC AdminService
+type AdminServer struct {
+	Inventory       *InventoryService
+	PresenceService *PresenceService
+	NotifyService   *NotifyService
+	Files           *FileService
+}
+
+//Clients returns the clients matching the request
+func (s AdminServer) Clients(ctx context.Context, r *rpc.ClientsRequest) (*rpc.ClientsResponse, error) {
+	clients, err := s.Inventory.Clients(&ClientFilter{Location: r.GetLocation(), Group: r.GetGroup(), Outdated: r.GetOutdated()})
+	if err != nil {
+		LogGRPC(ctx, "ClientsRequest", fmt.Sprintf("Error: %v", err))
+		return nil, err
+	}
+	resp := &rpc.ClientsResponse{Clients: make([]*rpc.Client, 0, len(clients))}
+	for _, c := range clients {
+		resp.Clients = append(resp.Clients, &rpc.Client{
+			HardwareAddr: c.HardwareAddr,
+			Location:     c.Location,
+			LastSeen:     c.LastSeen.Unix(),
+			Version:      c.Version,
+			Outdated:     c.Outdated,
+			Drift:        c.Drift,
+		})
+	}
+	LogGRPC(ctx, "ClientsRequest", fmt.Sprintf("Location: %s, Group: %s, Outdated: %v, Clients: %d",
+		r.GetLocation(), r.GetGroup(), r.GetOutdated(), len(resp.Clients)))
+	return resp, nil
+}
+
+//Presence returns the presence of known clients
+func (s AdminServer) Presence(ctx context.Context, r *rpc.PresenceRequest) (*rpc.PresenceResponse, error) {
+	clients := s.PresenceService.Clients(r.GetStale())
+	resp := &rpc.PresenceResponse{Clients: make([]*rpc.Presence, 0, len(clients))}
+	for _, p := range clients {
+		c := &rpc.Presence{
+			HardwareAddr:   p.HardwareAddr,
+			Connected:      p.Connected,
+			ReportInterval: int64(p.ReportInterval / time.Second),
+			Stale:          p.Stale,
+		}
+		if !p.ConnectedAt.IsZero() {
+			c.ConnectedAt = p.ConnectedAt.Unix()
+		}
+		if !p.DisconnectedAt.IsZero() {
+			c.DisconnectedAt = p.DisconnectedAt.Unix()
+		}
+		if !p.LastReport.IsZero() {
+			c.LastReport = p.LastReport.Unix()
+		}
+		resp.Clients = append(resp.Clients, c)
+	}
+	LogGRPC(ctx, "PresenceRequest", fmt.Sprintf("Stale: %v, Clients: %d", r.GetStale(), len(resp.Clients)))
+	return resp, nil
+}
+
+//DeleteClient deletes a disconnected client and its reports
+func (s AdminServer) DeleteClient(ctx context.Context, r *rpc.DeleteClientRequest) (*rpc.DeleteClientResponse, error) {
+	switch err := s.Inventory.Delete(r.GetHardwareAddr()); err {
+	case nil:
+		LogGRPC(ctx, "DeleteClientRequest", fmt.Sprintf("HardwareAddr: %s", r.GetHardwareAddr()))
+		return &rpc.DeleteClientResponse{}, nil
+	case db.ErrorNotFound:
+		return nil, grpc.Errorf(codes.NotFound, "client not found: %s", r.GetHardwareAddr())
+	case ErrorClientConnected:
+		return nil, grpc.Errorf(codes.FailedPrecondition, "client is connected: %s", r.GetHardwareAddr())
+	default:
+		LogGRPC(ctx, "DeleteClientRequest", fmt.Sprintf("HardwareAddr: %s, Error: %v", r.GetHardwareAddr(), err))
+		return nil, grpc.Errorf(codes.Internal, "error deleting client: %s", r.GetHardwareAddr())
+	}
+}
+
+//groupDiff converts gd to an *rpc.GroupDiff
+func groupDiff(group string, gd *GroupDiff) *rpc.GroupDiff {
+	return &rpc.GroupDiff{
+		Group:      group,
+		OldVersion: gd.OldVersion,
+		NewVersion: gd.NewVersion,
+		Added:      gd.Added,
+		Removed:    gd.Removed,
+		Modified:   gd.Modified,
+	}
+}
+
+//groupDiffs converts d to a slice of *rpc.GroupDiff sorted by group
+func groupDiffs(d Diff) []*rpc.GroupDiff {
+	diffs := make([]*rpc.GroupDiff, 0, len(d))
+	for group, gd := range d {
+		diffs = append(diffs, groupDiff(group, gd))
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Group < diffs[j].Group })
+	return diffs
+}
+
+//Reload reloads the definition, notifies clients of changed versions, and returns the changed groups and any warnings.
+//If the request is a dry run, the changes are only previewed
+func (s AdminServer) Reload(ctx context.Context, r *rpc.ReloadRequest) (*rpc.ReloadResponse, error) {
+	reload := s.NotifyService.Reload
+	if r.GetDryRun() {
+		reload = s.NotifyService.Preview
+	}
+	result, err := reload()
+	if err != nil {
+		LogGRPC(ctx, "ReloadRequest", fmt.Sprintf("DryRun: %v, Error: %v", r.GetDryRun(), err))
+		return nil, grpc.Errorf(codes.Internal, "error reloading definition")
+	}
+	LogGRPC(ctx, "ReloadRequest", fmt.Sprintf("DryRun: %v, Changed Groups: %d, Warnings: %d, Errors: %d",
+		r.GetDryRun(), len(result.Groups), len(result.Warnings), len(result.Errors)))
+	return &rpc.ReloadResponse{Groups: groupDiffs(result.Groups), DryRun: result.DryRun, Warnings: result.Warnings, Errors: result.Errors,
+		NotifyFailures: int64(result.NotifyFailures)}, nil
+}
+
+//History returns the recorded versions of a group
+func (s AdminServer) History(ctx context.Context, r *rpc.HistoryRequest) (*rpc.HistoryResponse, error) {
+	versions, err := s.Files.History(r.GetGroup())
+	if err == ErrorHistoryDisabled {
+		return nil, grpc.Errorf(codes.FailedPrecondition, "history disabled")
+	} else if err != nil {
+		LogGRPC(ctx, "HistoryRequest", fmt.Sprintf("Group: %s, Error: %v", r.GetGroup(), err))
+		return nil, grpc.Errorf(codes.Internal, "error getting history: %s", r.GetGroup())
+	}
+
+	resp := &rpc.HistoryResponse{Versions: make([]*rpc.HistoryVersion, 0, len(versions))}
+	for _, v := range versions {
+		resp.Versions = append(resp.Versions, &rpc.HistoryVersion{
+			Version:   v.Version,
+			Time:      v.Time.Unix(),
+			Files:     int64(v.Files),
+			Published: v.Published,
+		})
+	}
+	LogGRPC(ctx, "HistoryRequest", fmt.Sprintf("Group: %s, Versions: %d", r.GetGroup(), len(resp.Versions)))
+	return resp, nil
+}
+
+//Rollback publishes a recorded version of a group and notifies clients
+func (s AdminServer) Rollback(ctx context.Context, r *rpc.RollbackRequest) (*rpc.RollbackResponse, error) {
+	result, err := s.NotifyService.Rollback(r.GetGroup(), r.GetVersion())
+	switch err {
+	case nil:
+		LogGRPC(ctx, "RollbackRequest", fmt.Sprintf("Group: %s, Version: %d, Notify Failures: %d", r.GetGroup(), r.GetVersion(), result.NotifyFailures))
+		return &rpc.RollbackResponse{Diff: groupDiff(r.GetGroup(), result.GroupDiff), NotifyFailures: int64(result.NotifyFailures)}, nil
+	case ErrorHistoryDisabled:
+		return nil, grpc.Errorf(codes.FailedPrecondition, "history disabled")
+	case ErrorGroupNotFound, ErrorVersionNotFound:
+		return nil, grpc.Errorf(codes.NotFound, "%v: %s %d", err, r.GetGroup(), r.GetVersion())
+	default:
+		LogGRPC(ctx, "RollbackRequest", fmt.Sprintf("Group: %s, Version: %d, Error: %v", r.GetGroup(), r.GetVersion(), err))
+		return nil, grpc.Errorf(codes.Internal, "error rolling back: %s", r.GetGroup())
+	}
 }
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/korylprince/jettison/lib/file"
+)
+
+//ErrorVersionNotFound signals that a group has no history entry with the given version
+var ErrorVersionNotFound = fmt.Errorf("version not found")
+
+//HistoryEntry is a published version of a group
+type HistoryEntry struct {
+	Version uint64
+	Time    time.Time
+	Set     file.Set
+}
+
+//Pin is a group rolled back to a previous version.
+//The group stays at Version until its origins walk to a version other than Walked
+type Pin struct {
+	Version uint64
+	Walked  uint64 //the version of the group's origins when it was rolled back
+}
+
+//History stores the published versions of groups in boltdb and snapshots of their files in a content-addressed directory
+type History struct {
+	db        *bolt.DB
+	snapshots string
+	limit     int //number of versions kept per group
+}
+
+//NewHistory returns a new History with the given database and snapshot directory paths,
+//keeping limit versions per group, or an error if one occurred
+func NewHistory(path, snapshots string, limit int) (*History, error) {
+	if err := os.MkdirAll(snapshots, 0700); err != nil {
+		return nil, fmt.Errorf("Error creating snapshot directory: %v", err)
+	}
+
+	//fail instead of blocking forever if another process has the database open
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("Error opening history database: %v", err)
+	}
+
+	//remove snapshots left partially copied by a previous process. The database lock keeps other processes from using the directory
+	tmps, err := filepath.Glob(filepath.Join(snapshots, ".tmp-*"))
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Error reading snapshot directory: %v", err)
+	}
+	for _, tmp := range tmps {
+		if err = os.Remove(tmp); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("Error removing partial snapshot: %v", err)
+		}
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range []string{"history", "pins"} {
+			if _, txErr := tx.CreateBucketIfNotExists([]byte(bucket)); txErr != nil {
+				return txErr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &History{db: db, snapshots: snapshots, limit: limit}, nil
+}
+
+//Snapshot returns the path of the snapshot of the file with the given hash, if ok is true
+func (h *History) Snapshot(hash uint64) (path string, ok bool) {
+	path = filepath.Join(h.snapshots, strconv.FormatUint(hash, 10))
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+//snapshot copies origin to the snapshot directory if a snapshot of hash doesn't already exist.
+//An error is returned if origin no longer has the given hash
+func (h *History) snapshot(hash uint64, origin string) error {
+	if _, ok := h.Snapshot(hash); ok {
+		return nil
+	}
+
+	src, err := os.Open(origin)
+	if err != nil {
+		return fmt.Errorf("Error opening %s: %v", origin, err)
+	}
+	defer src.Close()
+
+	tmp, err := ioutil.TempFile(h.snapshots, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("Error creating snapshot: %v", err)
+	}
+
+	d := file.NewDigester(nil)
+	_, err = io.Copy(io.MultiWriter(tmp, d), src)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Error copying %s: %v", origin, err)
+	}
+
+	if sum, _ := d.Sum(); sum != hash {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Error copying %s: file changed since it was walked", origin)
+	}
+
+	if err = os.Rename(tmp.Name(), filepath.Join(h.snapshots, strconv.FormatUint(hash, 10))); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Error creating snapshot: %v", err)
+	}
+	return nil
+}
+
+//Record snapshots the files in vs from origins, a mapping of hashes to origin paths, and saves vs as the latest version of group.
+//Versions past the limit are removed, along with snapshots no longer used by any version.
+//If vs is already the latest version of group, nothing is recorded
+func (h *History) Record(group string, vs *file.VersionedSet, origins map[uint64]string) error {
+	entries, err := h.Entries(group)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 && entries[0].Version == vs.Version {
+		return nil
+	}
+
+	for _, entry := range vs.Set {
+		origin, ok := origins[entry.Hash]
+		if !ok {
+			return fmt.Errorf("Error snapshotting %d: no origin", entry.Hash)
+		}
+		if err = h.snapshot(entry.Hash, origin); err != nil {
+			return err
+		}
+	}
+
+	buf, err := json.Marshal(&HistoryEntry{Version: vs.Version, Time: time.Now(), Set: vs.Set})
+	if err != nil {
+		return err
+	}
+
+	evicted := 0
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("history"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: history")
+		}
+		g, err := b.CreateBucketIfNotExists([]byte(group))
+		if err != nil {
+			return err
+		}
+
+		//keys are big endian sequence numbers so they iterate in publish order
+		seq, err := g.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		if err = g.Put(key, buf); err != nil {
+			return err
+		}
+
+		var keys [][]byte
+		g.ForEach(func(k, v []byte) error {
+			keys = append(keys, k)
+			return nil
+		})
+		for ; len(keys)-evicted > h.limit; evicted++ {
+			if err = g.Delete(keys[evicted]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if evicted > 0 {
+		return h.prune()
+	}
+	return nil
+}
+
+//prune removes snapshots that aren't used by any version
+func (h *History) prune() error {
+	used := make(map[string]struct{})
+	err := h.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("history"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: history")
+		}
+		return b.ForEach(func(group, v []byte) error {
+			return b.Bucket(group).ForEach(func(k, v []byte) error {
+				entry := new(HistoryEntry)
+				if err := json.Unmarshal(v, entry); err != nil {
+					return err
+				}
+				for _, e := range entry.Set {
+					used[strconv.FormatUint(e.Hash, 10)] = struct{}{}
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	infos, err := ioutil.ReadDir(h.snapshots)
+	if err != nil {
+		return fmt.Errorf("Error reading snapshot directory: %v", err)
+	}
+	for _, info := range infos {
+		//only snapshots are removed since the directory may be shared with other files
+		if _, err = strconv.ParseUint(info.Name(), 10, 64); err != nil || info.IsDir() {
+			continue
+		}
+		if _, ok := used[info.Name()]; !ok {
+			if err = os.Remove(filepath.Join(h.snapshots, info.Name())); err != nil {
+				return fmt.Errorf("Error removing snapshot: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+//Entries returns the recorded versions of group, newest first
+func (h *History) Entries(group string) ([]*HistoryEntry, error) {
+	var entries []*HistoryEntry
+	err := h.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("history"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: history")
+		}
+		g := b.Bucket([]byte(group))
+		if g == nil {
+			return nil
+		}
+		c := g.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			entry := new(HistoryEntry)
+			if err := json.Unmarshal(v, entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+//Get returns the recorded version of group, or ErrorVersionNotFound if it doesn't exist
+func (h *History) Get(group string, version uint64) (*HistoryEntry, error) {
+	entries, err := h.Entries(group)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Version == version {
+			return entry, nil
+		}
+	}
+	return nil, ErrorVersionNotFound
+}
+
+//Pins returns the pinned groups, map[group]*Pin
+func (h *History) Pins() (map[string]*Pin, error) {
+	pins := make(map[string]*Pin)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("pins"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: pins")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			p := new(Pin)
+			if err := json.Unmarshal(v, p); err != nil {
+				return err
+			}
+			pins[string(k)] = p
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
+
+//Pin saves p for group
+func (h *History) Pin(group string, p *Pin) error {
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("pins"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: pins")
+		}
+		return b.Put([]byte(group), buf)
+	})
+}
+
+//Unpin removes the Pin for group
+func (h *History) Unpin(group string) error {
+	return h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("pins"))
+		if b == nil {
+			return fmt.Errorf("invalid bucket: pins")
+		}
+		return b.Delete([]byte(group))
+	})
+}
+
+//Close closes the underlying boltdb database
+func (h *History) Close() error {
+	return h.db.Close()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/korylprince/jettison/lib/file"
+)
+
+func TestHistoryRecordEviction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison-history-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h, err := NewHistory(filepath.Join(dir, "history.db"), filepath.Join(dir, "snapshots"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	//each version has a single file with different content
+	var versions []*file.VersionedSet
+	var hashes []uint64
+	for i := 0; i < 4; i++ {
+		origin := filepath.Join(dir, fmt.Sprintf("origin%d", i))
+		if err = ioutil.WriteFile(origin, []byte(fmt.Sprintf("version %d", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		hash, _, err := file.HashDigest(origin, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs := file.NewVersionedSet(file.Set{"/dest": {Hash: hash}})
+		if err = h.Record("test", vs, map[uint64]string{hash: origin}); err != nil {
+			t.Fatalf("Record version %d: %v", i, err)
+		}
+		//recording the latest version again is a no-op
+		if err = h.Record("test", vs, map[uint64]string{hash: origin}); err != nil {
+			t.Fatalf("Record version %d again: %v", i, err)
+		}
+		versions = append(versions, vs)
+		hashes = append(hashes, hash)
+	}
+
+	entries, err := h.Entries("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if expected := versions[3-i].Version; entry.Version != expected {
+			t.Errorf("entry %d: expected version %d, got %d", i, expected, entry.Version)
+		}
+	}
+
+	for i, hash := range hashes {
+		_, ok := h.Snapshot(hash)
+		if kept := i >= 2; ok != kept {
+			t.Errorf("snapshot of version %d: expected exists %v, got %v", i, kept, ok)
+		}
+	}
+
+	if _, err = h.Get("test", versions[0].Version); err != ErrorVersionNotFound {
+		t.Errorf("evicted version: expected %v, got %v", ErrorVersionNotFound, err)
+	}
+}
+
+func TestHistoryPruneSharedDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison-history-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	//the snapshots share a directory with the database, a partial snapshot, and unrelated files
+	unrelated := []string{"cache.db", "notes", "123abc"}
+	for _, name := range unrelated {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.Mkdir(filepath.Join(dir, "42"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, ".tmp-partial"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewHistory(filepath.Join(dir, "history.db"), dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	if _, err = os.Stat(filepath.Join(dir, ".tmp-partial")); !os.IsNotExist(err) {
+		t.Errorf("partial snapshot: expected to be removed, got %v", err)
+	}
+
+	var origins []string
+	for i := 0; i < 2; i++ {
+		origin := filepath.Join(dir, fmt.Sprintf("origin%d", i))
+		if err = ioutil.WriteFile(origin, []byte(fmt.Sprintf("version %d", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		hash, _, err := file.HashDigest(origin, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = h.Record("test", file.NewVersionedSet(file.Set{"/dest": {Hash: hash}}), map[uint64]string{hash: origin}); err != nil {
+			t.Fatalf("Record version %d: %v", i, err)
+		}
+		origins = append(origins, filepath.Base(origin))
+	}
+
+	for _, name := range append(append(unrelated, "history.db", "42"), origins...) {
+		if _, err = os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: expected to be kept, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+//writeJSON writes v to w as JSON, logging any errors encountered
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Error encoding JSON:", err)
+	}
+}
+
+//writeJSONError writes a JSON error for the given HTTP status code to w
+func writeJSONError(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	w.Write([]byte(fmt.Sprintf(`{"error":%d,"msg":"%s"}`, code, http.StatusText(code))))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/korylprince/jettison/lib/db"
+)
+
+//Client represents the last known state of a client
+type Client struct {
+	HardwareAddr string
+	Location     string
+	LastSeen     time.Time
+	Version      map[string]uint64 //group:version
+	Outdated     []string          //groups whose version differs from the published version
+	Drift        map[string]uint64 `json:",omitempty"` //group:files repaired in the client's last verification pass
+}
+
+//ClientFilter filters the clients returned by InventoryService.Clients. Empty fields match all clients
+type ClientFilter struct {
+	Location string
+	Group    string
+	Outdated bool //only match clients with outdated groups
+}
+
+//ErrorClientConnected signals that a connected client can't be deleted
+var ErrorClientConnected = fmt.Errorf("client is connected")
+
+//InventoryService queries and manages stored client reports
+type InventoryService struct {
+	files    *FileService
+	reports  db.Store
+	presence *PresenceService
+}
+
+//NewInventoryService returns a new InventoryService
+func NewInventoryService(files *FileService, reports db.Store, presence *PresenceService) *InventoryService {
+	return &InventoryService{files: files, reports: reports, presence: presence}
+}
+
+//client returns a *Client for rpt, comparing its versions with published versions
+func (s *InventoryService) client(rpt *db.Report) *Client {
+	c := &Client{
+		HardwareAddr: rpt.HardwareAddr,
+		Location:     rpt.Location,
+		LastSeen:     rpt.Time,
+		Version:      rpt.Version,
+		Drift:        rpt.Drift,
+	}
+
+	var groups []string
+	for group := range rpt.Version {
+		groups = append(groups, group)
+	}
+	sets := s.files.Sets(groups...)
+
+	for group, ver := range rpt.Version {
+		if vs, ok := sets[group]; !ok || vs.Version != ver {
+			c.Outdated = append(c.Outdated, group)
+		}
+	}
+	sort.Strings(c.Outdated)
+
+	return c
+}
+
+//Clients returns all clients matching filter, sorted by hardware address
+func (s *InventoryService) Clients(filter *ClientFilter) ([]*Client, error) {
+	rpts, err := s.reports.All()
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]*Client, 0, len(rpts))
+	for _, rpt := range rpts {
+		if filter.Location != "" && rpt.Location != filter.Location {
+			continue
+		}
+		if _, ok := rpt.Version[filter.Group]; filter.Group != "" && !ok {
+			continue
+		}
+		c := s.client(rpt)
+		if filter.Outdated && len(c.Outdated) == 0 {
+			continue
+		}
+		clients = append(clients, c)
+	}
+
+	sort.Slice(clients, func(i, j int) bool { return clients[i].HardwareAddr < clients[j].HardwareAddr })
+
+	return clients, nil
+}
+
+//Client returns the client with the given hardware address along with its report history
+func (s *InventoryService) Client(hardwareAddr string) (*Client, []*db.Report, error) {
+	rpt, err := s.reports.Latest(hardwareAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	history, err := s.reports.History(hardwareAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.client(rpt), history, nil
+}
+
+//Delete removes a disconnected client and its reports, returning ErrorClientConnected if it's connected,
+//or db.ErrorNotFound if it doesn't exist
+func (s *InventoryService) Delete(hardwareAddr string) error {
+	if !s.presence.Forget(hardwareAddr) {
+		return ErrorClientConnected
+	}
+	return s.reports.Delete(hardwareAddr)
+}
+
+//ServeHTTP satisfies http.Handler, returning clients matching the location, group and outdated
+//query parameters in JSON, or a single client and its history if a hardware address is given in the path.
+//DELETE requests with a hardware address delete the client
+func (s *InventoryService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if addr, ok := mux.Vars(r)["hardwareAddr"]; ok && r.Method == "DELETE" {
+		switch err := s.Delete(addr); err {
+		case nil:
+			writeJSON(w, &struct{ HardwareAddr string }{HardwareAddr: addr})
+		case db.ErrorNotFound:
+			writeJSONError(w, http.StatusNotFound)
+		case ErrorClientConnected:
+			writeJSONError(w, http.StatusConflict)
+		default:
+			log.Println("InventoryService: Error deleting client:", err)
+			writeJSONError(w, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if addr, ok := mux.Vars(r)["hardwareAddr"]; ok {
+		c, history, err := s.Client(addr)
+		if err == db.ErrorNotFound {
+			writeJSONError(w, http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Println("InventoryService: Error getting client:", err)
+			writeJSONError(w, http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, &struct {
+			*Client
+			History []*db.Report
+		}{Client: c, History: history})
+		return
+	}
+
+	q := r.URL.Query()
+	clients, err := s.Clients(&ClientFilter{
+		Location: q.Get("location"),
+		Group:    q.Get("group"),
+		Outdated: q.Get("outdated") == "true",
+	})
+	if err != nil {
+		log.Println("InventoryService: Error getting clients:", err)
+		writeJSONError(w, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, clients)
+}
@@ -8,9 +8,12 @@ import (
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/korylprince/jettison/lib/db"
+	"github.com/korylprince/jettison/lib/file"
 	"github.com/korylprince/jettison/lib/rpc"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -20,25 +23,117 @@ func main() {
 	}
 	log.Printf("Config: %#v\n", *config)
 
-	files, err := FilesFromDefinition(config.DefinitionPath, config.CachePath)
+	var hasher file.Hasher
+	if config.HashAlgorithm != "" {
+		hasher, _ = file.NewHasher(config.HashAlgorithm) //validated by ParseEnv
+	}
+
+	var history *History
+	if config.HistoryPath != "" {
+		if history, err = NewHistory(config.HistoryPath, config.SnapshotPath, config.HistoryLimit); err != nil {
+			log.Fatalln("Error creating History:", err)
+		}
+		defer history.Close()
+	}
+
+	files, err := FilesFromDefinition(config.DefinitionPath, config.CachePath, hasher, config.TolerantReload, history)
 	if err != nil {
 		log.Fatalln("Error creating Files:", err)
 	}
 	defer files.Close()
 
+	var reports db.Store
+	if config.ReportPath != "" {
+		reports, err = db.NewBoltStore(config.ReportPath)
+		if err != nil {
+			log.Fatalln("Error opening report store:", err)
+		}
+	} else {
+		log.Println("JETTISON_REPORTPATH not configured, keeping reports in memory")
+		reports = db.NewMemoryStore()
+	}
+	defer reports.Close()
+
 	notifyService := NewNotifyService(config, files)
 
+	if !config.DisableWatch {
+		watcher, err := NewWatcher(config, notifyService)
+		if err != nil {
+			log.Fatalln("Error creating Watcher:", err)
+		}
+		defer watcher.Close()
+		go watcher.Watch()
+	}
+
+	presence, err := NewPresenceService(config, reports)
+	if err != nil {
+		log.Fatalln("Error creating Presence:", err)
+	}
+	inventory := NewInventoryService(files, reports, presence)
+	status := NewStatusService(files, notifyService, presence)
+
+	var policy *Policy
+	if config.PolicyPath != "" {
+		if policy, err = LoadPolicy(config.PolicyPath); err != nil {
+			log.Fatalln("Error loading policy:", err)
+		}
+	} else {
+		log.Println("JETTISON_POLICYPATH not configured, allowing all clients access to all groups")
+	}
+
+	if config.AdminPath == "" && config.AdminLoopback {
+		log.Println("JETTISON_ADMINPATH not configured, allowing admin access from loopback addresses without credentials")
+	} else if config.AdminPath == "" {
+		log.Println("JETTISON_ADMINPATH not configured, denying all admin access")
+	}
+	admin, err := NewAdminAuth(config.AdminPath, config.AuditPath, config.AdminLoopback)
+	if err != nil {
+		log.Fatalln("Error loading admins:", err)
+	}
+
+	tlsConfig, err := TLSConfig(config)
+	if err != nil {
+		log.Fatalln("Error configuring TLS:", err)
+	}
+
 	mux := mux.NewRouter()
-	mux.Methods("GET").PathPrefix("/file/").Handler(http.StripPrefix("/file/", http.FileServer(files)))
-	mux.Methods("GET").Path("/sets").Handler(files)
-	mux.Methods("POST").Path("/reload").Handler(notifyService)
-	server := &http.Server{Addr: config.HTTPListenAddr, Handler: handlers.CombinedLoggingHandler(os.Stdout, mux)}
+	mux.Methods("GET").PathPrefix("/file/").Handler(http.StripPrefix("/file/", policy.FileHandler(files, http.FileServer(files))))
+	mux.Methods("GET").Path("/sets").Handler(admin.Handler(RoleReadOnly, files))
+	mux.Methods("GET").Path("/sets/{group}/history").Handler(admin.Handler(RoleReadOnly, http.HandlerFunc(files.ServeHistory)))
+	mux.Methods("POST").Path("/sets/{group}/rollback").Handler(admin.Handler(RoleOperator, http.HandlerFunc(notifyService.ServeRollback)))
+	mux.Methods("GET").Path("/status").Handler(admin.Handler(RoleReadOnly, status))
+	mux.Methods("POST").Path("/reload").Handler(admin.Handler(RoleOperator, notifyService))
+	mux.Methods("GET").Path("/clients").Handler(admin.Handler(RoleReadOnly, inventory))
+	mux.Methods("GET").Path("/presence").Handler(admin.Handler(RoleReadOnly, presence))
+	mux.Methods("GET").Path("/clients/{hardwareAddr}").Handler(admin.Handler(RoleReadOnly, inventory))
+	mux.Methods("DELETE").Path("/clients/{hardwareAddr}").Handler(admin.Handler(RoleOperator, inventory))
+
+	if config.HTTPListenAddr != "" {
+		server := &http.Server{Addr: config.HTTPListenAddr, Handler: handlers.CombinedLoggingHandler(os.Stdout, mux), TLSConfig: tlsConfig}
+		go func() {
+			var err error
+			if tlsConfig != nil {
+				err = server.ListenAndServeTLS("", "") //certificates are in TLSConfig
+			} else {
+				err = server.ListenAndServe()
+			}
+			log.Fatalf("Error listening on %s: %v", config.HTTPListenAddr, err)
+		}()
+	} else {
+		log.Println("HTTP listener disabled: HTTP file downloads and the admin HTTP API are unavailable")
+	}
+
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
 
-	go server.ListenAndServe()
+	opts = append(opts, grpc.UnaryInterceptor(admin.UnaryInterceptor(adminServicePrefix, AdminRoles)))
 
-	s := grpc.NewServer()
-	rpc.RegisterFileSetServer(s, &FileSetServer{Files: files})
-	rpc.RegisterEventsServer(s, &EventServer{NotifyService: notifyService})
+	s := grpc.NewServer(opts...)
+	rpc.RegisterFileSetServer(s, &FileSetServer{Files: files, Policy: policy})
+	rpc.RegisterEventsServer(s, &EventServer{NotifyService: notifyService, PresenceService: presence, Reports: reports, Policy: policy})
+	rpc.RegisterAdminServer(s, &AdminServer{Inventory: inventory, PresenceService: presence, NotifyService: notifyService, Files: files})
 
 	lis, err := net.Listen("tcp", config.RPCListenAddr)
 	if err != nil {
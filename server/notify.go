@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/korylprince/jettison/lib/rpc"
 )
 
@@ -15,6 +18,10 @@ type NotifyService struct {
 	files    *FileService
 	registry map[string]map[rpc.Events_StreamServer]struct{} //group:set{connections}
 	mu       *sync.RWMutex
+	reloadMu *sync.Mutex //serializes reloads
+
+	lastReload    time.Time
+	lastReloadErr error
 }
 
 //NewNotifyService returns a new NotifyService
@@ -24,6 +31,7 @@ func NewNotifyService(config *Config, files *FileService) *NotifyService {
 		files:    files,
 		registry: make(map[string]map[rpc.Events_StreamServer]struct{}),
 		mu:       new(sync.RWMutex),
+		reloadMu: new(sync.Mutex),
 	}
 }
 
@@ -50,38 +58,149 @@ func (s *NotifyService) Unregister(stream rpc.Events_StreamServer, groups ...str
 	s.mu.Unlock()
 }
 
-//Notify notifies the streams (if any) registered to the given groups of version changes
+//NotifyError signals that some streams couldn't be notified
+type NotifyError struct {
+	Failed int //number of notifications that couldn't be sent
+}
+
+func (e *NotifyError) Error() string {
+	return fmt.Sprintf("Error sending %d notifications", e.Failed)
+}
+
+//Notify notifies the streams (if any) registered to the given groups of version changes.
+//Every stream is notified even if some fail, and a *NotifyError is returned if any failed
 func (s *NotifyService) Notify(groups map[string]uint64) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	failed := 0
 	for group, ver := range groups {
 		if _, ok := s.registry[group]; ok {
 			for stream := range s.registry[group] {
 				err := stream.Send(&rpc.Notification{Group: group, Version: ver})
 				if err != nil {
-					LogGRPC(stream.Context(), "Notification", fmt.Sprintf("Error: %v", err))
-					return err
+					LogGRPC(stream.Context(), "Notification", fmt.Sprintf("Group: %s, Version: %d, Error: %v", group, ver, err))
+					failed++
+					continue
 				}
 				LogGRPC(stream.Context(), "Notification", fmt.Sprintf("Group: %s, Version: %d", group, ver))
 			}
 		}
 	}
+	if failed > 0 {
+		return &NotifyError{Failed: failed}
+	}
 	return nil
 }
 
-//ServeHTTP satisfies http.Handler, reloading the underlying Definition and Files,
-//notifying registered streams of changed versions, and logging and returning any errors encountered
-func (s *NotifyService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	groups, err := s.files.CheckDefinition(s.config.DefinitionPath)
+//Reload reloads the underlying Definition and Files, notifies registered streams of changed versions,
+//and returns the changed groups and any warnings. An error is only returned if the definition couldn't be published;
+//notifications that couldn't be sent are logged and counted in the result. Only one reload runs at a time
+func (s *NotifyService) Reload() (*ReloadResult, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	result, err := s.files.CheckDefinition(s.config.DefinitionPath, false)
+	if err != nil {
+		err = fmt.Errorf("Error reloading definition: %v", err)
+	} else {
+		for _, w := range result.Warnings {
+			log.Println("Reload Warning:", w)
+		}
+		if nErr := s.Notify(result.Groups.Versions()); nErr != nil {
+			log.Println("Reload:", nErr)
+			result.NotifyFailures = nErr.(*NotifyError).Failed
+		}
+	}
+
+	s.mu.Lock()
+	s.lastReload, s.lastReloadErr = time.Now(), err
+	s.mu.Unlock()
+
+	return result, err
+}
+
+//Preview returns the changes and warnings a reload would produce, without publishing them or notifying streams
+func (s *NotifyService) Preview() (*ReloadResult, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	result, err := s.files.CheckDefinition(s.config.DefinitionPath, true)
+	if err != nil {
+		return nil, fmt.Errorf("Error previewing definition: %v", err)
+	}
+	return result, nil
+}
+
+//RollbackResult is the result of rolling back a group
+type RollbackResult struct {
+	*GroupDiff
+	NotifyFailures int `json:",omitempty"` //number of notifications of the rollback that couldn't be sent
+}
+
+//Rollback publishes the recorded version of group, notifies registered streams, and returns the changes.
+//An error is only returned if the rollback couldn't be published;
+//notifications that couldn't be sent are logged and counted in the result.
+//Rollbacks and reloads don't run at the same time
+func (s *NotifyService) Rollback(group string, version uint64) (*RollbackResult, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	diff, err := s.files.Rollback(group, version)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("NotifyService: Rolled back group %s to version %d\n", group, version)
+
+	result := &RollbackResult{GroupDiff: diff}
+	if nErr := s.Notify(map[string]uint64{group: version}); nErr != nil {
+		log.Println("Rollback:", nErr)
+		result.NotifyFailures = nErr.(*NotifyError).Failed
+	}
+	return result, nil
+}
+
+//ServeRollback is an http.HandlerFunc, rolling back the group in the URL to the version query parameter
+//and returning the changes in JSON
+func (s *NotifyService) ServeRollback(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.ParseUint(r.URL.Query().Get("version"), 10, 64)
 	if err != nil {
-		log.Println("Error reloading definition:", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusBadRequest)
 		return
 	}
-	err = s.Notify(groups)
+
+	result, err := s.Rollback(mux.Vars(r)["group"], version)
+	switch err {
+	case nil:
+		writeJSON(w, result)
+	case ErrorHistoryDisabled, ErrorGroupNotFound, ErrorVersionNotFound:
+		writeJSONError(w, http.StatusNotFound)
+	default:
+		log.Println("NotifyService: Error rolling back:", err)
+		writeJSONError(w, http.StatusInternalServerError)
+	}
+}
+
+//LastReload returns the time and error, if any, of the last reload
+func (s *NotifyService) LastReload() (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastReload, s.lastReloadErr
+}
+
+//ServeHTTP satisfies http.Handler, reloading the underlying Definition and Files,
+//notifying registered streams of changed versions, and returning the ReloadResult in JSON.
+//If the dry_run query parameter is true, the reload is only previewed.
+//Errors encountered are logged and returned
+func (s *NotifyService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reload := s.Reload
+	if r.URL.Query().Get("dry_run") == "true" {
+		reload = s.Preview
+	}
+	result, err := reload()
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Println(err)
+		writeJSONError(w, http.StatusInternalServerError)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	writeJSON(w, result)
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/korylprince/jettison/lib/rpc"
+)
+
+//testStream is an rpc.Events_StreamServer that records notifications
+type testStream struct {
+	rpc.Events_StreamServer
+	fail bool
+	sent []*rpc.Notification
+}
+
+func (s *testStream) Send(n *rpc.Notification) error {
+	if s.fail {
+		return errors.New("stream closed")
+	}
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func (s *testStream) Context() context.Context {
+	return context.Background()
+}
+
+func TestNotifyAllStreams(t *testing.T) {
+	s := NewNotifyService(&Config{}, nil)
+	streams := []*testStream{{}, {fail: true}, {}}
+	for _, stream := range streams {
+		s.Register(stream, "all")
+	}
+
+	err := s.Notify(map[string]uint64{"all": 1})
+	if nErr, ok := err.(*NotifyError); !ok || nErr.Failed != 1 {
+		t.Fatalf("expected *NotifyError with 1 failure, got %v", err)
+	}
+	for i, stream := range streams {
+		if !stream.fail && len(stream.sent) != 1 {
+			t.Errorf("stream %d: expected 1 notification, got %d", i, len(stream.sent))
+		}
+	}
+}
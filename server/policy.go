@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+//Caller identifies a client making a request
+type Caller struct {
+	Identity     string //certificate common name or token identity. Empty if unauthenticated
+	HardwareAddr string //as reported by the client
+	Location     string //as reported by the client
+}
+
+func (c *Caller) String() string {
+	return fmt.Sprintf("{Identity: %s, HardwareAddr: %s, Location: %s}", c.Identity, c.HardwareAddr, c.Location)
+}
+
+//Rule allows the callers it matches access to Groups.
+//A Rule only matches authenticated callers, and only if every non-empty list contains the caller's value.
+//HardwareAddrs and Locations are reported by the client, so they only narrow a Rule.
+//"*" in Identities matches any authenticated caller
+type Rule struct {
+	Identities    []string `json:"identities"`
+	HardwareAddrs []string `json:"hardware_addrs"`
+	Locations     []string `json:"locations"`
+	Groups        []string `json:"groups"`
+}
+
+//match returns true if values is empty or contains v
+func match(values []string, v string, wildcard bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, val := range values {
+		if val == v || (wildcard && val == "*" && v != "") {
+			return true
+		}
+	}
+	return false
+}
+
+//Matches returns true if r matches c. Unauthenticated callers never match
+func (r *Rule) Matches(c *Caller) bool {
+	if c.Identity == "" {
+		return false
+	}
+	return match(r.Identities, c.Identity, true) &&
+		match(r.HardwareAddrs, strings.ToLower(c.HardwareAddr), false) &&
+		match(r.Locations, c.Location, false)
+}
+
+//Policy restricts the groups clients can access.
+//A nil *Policy allows every caller access to every group
+type Policy struct {
+	Tokens map[string]string `json:"tokens"` //enrollment token:identity
+	Rules  []*Rule           `json:"rules"`
+}
+
+//LoadPolicy returns the Policy in the JSON file at path or an error if one occurred
+func LoadPolicy(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening policy: %v", err)
+	}
+	defer f.Close()
+
+	p := new(Policy)
+	if err = json.NewDecoder(f).Decode(p); err != nil {
+		return nil, fmt.Errorf("Error decoding policy: %v", err)
+	}
+	for _, r := range p.Rules {
+		for i, addr := range r.HardwareAddrs {
+			r.HardwareAddrs[i] = strings.ToLower(addr)
+		}
+	}
+	return p, nil
+}
+
+//Allow returns true if c may access group. Only authenticated callers are allowed access
+func (p *Policy) Allow(c *Caller, group string) bool {
+	if p == nil {
+		return true
+	}
+	for _, r := range p.Rules {
+		if !r.Matches(c) {
+			continue
+		}
+		for _, g := range r.Groups {
+			if g == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//Filter returns the groups c may access and the groups it may not
+func (p *Policy) Filter(c *Caller, groups []string) (allowed, denied []string) {
+	for _, group := range groups {
+		if p.Allow(c, group) {
+			allowed = append(allowed, group)
+		} else {
+			denied = append(denied, group)
+		}
+	}
+	return allowed, denied
+}
+
+//AllowFile returns true if c may access any group containing the file with the given hash
+func (p *Policy) AllowFile(c *Caller, files *FileService, hash uint64) bool {
+	if p == nil {
+		return true
+	}
+	for _, group := range files.Groups(hash) {
+		if p.Allow(c, group) {
+			return true
+		}
+	}
+	return false
+}
+
+//identity returns the identity for the given token, or an empty string if it's unknown
+func (p *Policy) identity(token string) string {
+	if p == nil || token == "" {
+		return ""
+	}
+	return p.Tokens[token]
+}
+
+//GRPCCaller returns the Caller for the given RPC context, identified by its verified client certificate,
+//or the "token" metadata if it has none. "hardware_addr" and "location" are read from metadata
+func (p *Policy) GRPCCaller(ctx context.Context) *Caller {
+	c := new(Caller)
+	if pr, ok := peer.FromContext(ctx); ok {
+		if info, ok := pr.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			c.Identity = info.State.VerifiedChains[0][0].Subject.CommonName
+		}
+	}
+	if md, ok := metadata.FromContext(ctx); ok {
+		if c.Identity == "" && len(md["token"]) > 0 {
+			c.Identity = p.identity(md["token"][0])
+		}
+		if len(md["hardware_addr"]) > 0 {
+			c.HardwareAddr = md["hardware_addr"][0]
+		}
+		if len(md["location"]) > 0 {
+			c.Location = md["location"][0]
+		}
+	}
+	return c
+}
+
+//HTTPCaller returns the Caller for the given request, identified by its verified client certificate,
+//or an "Authorization: Bearer <token>" header if it has none. The hardware address and location
+//are read from the X-Jettison-Hardware-Addr and X-Jettison-Location headers
+func (p *Policy) HTTPCaller(r *http.Request) *Caller {
+	c := &Caller{
+		HardwareAddr: r.Header.Get("X-Jettison-Hardware-Addr"),
+		Location:     r.Header.Get("X-Jettison-Location"),
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		c.Identity = r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	if auth := r.Header.Get("Authorization"); c.Identity == "" && strings.HasPrefix(auth, "Bearer ") {
+		c.Identity = p.identity(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return c
+}
+
+//FileHandler returns an http.Handler that only passes requests for /<hash> on to next
+//if the caller may access a group containing the file
+func (p *Policy) FileHandler(files *FileService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hash, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if c := p.HTTPCaller(r); !p.AllowFile(c, files, hash) {
+			log.Printf("Policy: Denied file %d to %v\n", hash, c)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestPolicyAllow(t *testing.T) {
+	p := &Policy{Rules: []*Rule{
+		{Identities: []string{"*"}, Groups: []string{"all"}},
+		{Identities: []string{"lab"}, Locations: []string{"Lab"}, Groups: []string{"lab"}},
+		{HardwareAddrs: []string{"aa:bb:cc:dd:ee:ff"}, Groups: []string{"secret"}},
+		{Locations: []string{"Office"}, Groups: []string{"office"}},
+	}}
+
+	tests := []struct {
+		name   string
+		caller *Caller
+		group  string
+		allow  bool
+	}{
+		{"wildcard identity", &Caller{Identity: "any"}, "all", true},
+		{"unauthenticated wildcard", &Caller{}, "all", false},
+		{"identity and location", &Caller{Identity: "lab", Location: "Lab"}, "lab", true},
+		{"wrong location", &Caller{Identity: "lab", Location: "Office"}, "lab", false},
+		{"wrong identity", &Caller{Identity: "other", Location: "Lab"}, "lab", false},
+		{"hardware address", &Caller{Identity: "any", HardwareAddr: "AA:BB:CC:DD:EE:FF"}, "secret", true},
+		{"forged hardware address", &Caller{HardwareAddr: "aa:bb:cc:dd:ee:ff"}, "secret", false},
+		{"forged location", &Caller{Location: "Office"}, "office", false},
+		{"unlisted group", &Caller{Identity: "any"}, "none", false},
+	}
+
+	for _, test := range tests {
+		if allow := p.Allow(test.caller, test.group); allow != test.allow {
+			t.Errorf("%s: expected %v, got %v", test.name, test.allow, allow)
+		}
+	}
+
+	var nilPolicy *Policy
+	if !nilPolicy.Allow(&Caller{}, "all") {
+		t.Error("nil Policy: expected access")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/korylprince/jettison/lib/db"
+)
+
+//Presence represents the connection state of a client
+type Presence struct {
+	HardwareAddr   string
+	Connected      bool
+	ConnectedAt    time.Time
+	DisconnectedAt time.Time
+	LastReport     time.Time
+	ReportInterval time.Duration
+	Stale          bool
+
+	streams int //number of open event streams
+}
+
+//PresenceService tracks clients connecting and disconnecting from the Events stream
+type PresenceService struct {
+	config  *Config
+	clients map[string]*Presence //hardwareAddr:Presence
+	mu      *sync.RWMutex
+}
+
+//NewPresenceService returns a new PresenceService, seeded as disconnected with the clients in reports
+func NewPresenceService(config *Config, reports db.Store) (*PresenceService, error) {
+	s := &PresenceService{
+		config:  config,
+		clients: make(map[string]*Presence),
+		mu:      new(sync.RWMutex),
+	}
+
+	rpts, err := reports.All()
+	if err != nil {
+		return nil, err
+	}
+	for _, rpt := range rpts {
+		s.clients[rpt.HardwareAddr] = &Presence{
+			HardwareAddr:   rpt.HardwareAddr,
+			LastReport:     rpt.Time,
+			ReportInterval: config.ReportInterval * time.Second,
+		}
+	}
+
+	return s, nil
+}
+
+//get returns the *Presence for hardwareAddr, creating it if it doesn't exist. s.mu must be held
+func (s *PresenceService) get(hardwareAddr string) *Presence {
+	p, ok := s.clients[hardwareAddr]
+	if !ok {
+		p = &Presence{HardwareAddr: hardwareAddr, ReportInterval: s.config.ReportInterval * time.Second}
+		s.clients[hardwareAddr] = p
+	}
+	return p
+}
+
+//Connect marks the client as connected, expecting reports every interval.
+//If interval is 0, the configured default is used
+func (s *PresenceService) Connect(hardwareAddr string, interval time.Duration) {
+	s.mu.Lock()
+	p := s.get(hardwareAddr)
+	p.streams++
+	p.Connected = true
+	p.ConnectedAt = time.Now()
+	if interval > 0 {
+		p.ReportInterval = interval
+	}
+	s.mu.Unlock()
+}
+
+//Disconnect marks the client as disconnected once all of its streams have closed
+func (s *PresenceService) Disconnect(hardwareAddr string) {
+	s.mu.Lock()
+	p := s.get(hardwareAddr)
+	if p.streams > 0 {
+		p.streams--
+	}
+	if p.streams == 0 {
+		p.Connected = false
+		p.DisconnectedAt = time.Now()
+	}
+	s.mu.Unlock()
+}
+
+//Report records that the client sent a report
+func (s *PresenceService) Report(hardwareAddr string) {
+	s.mu.Lock()
+	s.get(hardwareAddr).LastReport = time.Now()
+	s.mu.Unlock()
+}
+
+//Forget removes the client if it isn't connected, returning false if it's connected
+func (s *PresenceService) Forget(hardwareAddr string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.clients[hardwareAddr]; ok && p.Connected {
+		return false
+	}
+	delete(s.clients, hardwareAddr)
+	return true
+}
+
+//Connected returns the number of connected clients
+func (s *PresenceService) Connected() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, p := range s.clients {
+		if p.Connected {
+			n++
+		}
+	}
+	return n
+}
+
+//Clients returns the presence of all known clients, sorted by hardware address.
+//If stale is true, only stale clients are returned.
+//A client is stale if it hasn't reported in StaleIntervals report intervals
+func (s *PresenceService) Clients(stale bool) []*Presence {
+	now := time.Now()
+	clients := make([]*Presence, 0)
+
+	s.mu.RLock()
+	for _, p := range s.clients {
+		c := *p
+
+		last := c.LastReport
+		if c.ConnectedAt.After(last) {
+			last = c.ConnectedAt
+		}
+		c.Stale = now.Sub(last) > time.Duration(s.config.StaleIntervals)*c.ReportInterval
+
+		if stale && !c.Stale {
+			continue
+		}
+		clients = append(clients, &c)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(clients, func(i, j int) bool { return clients[i].HardwareAddr < clients[j].HardwareAddr })
+
+	return clients
+}
+
+//ServeHTTP satisfies http.Handler, returning the presence of all clients in JSON,
+//or only stale clients if the stale query parameter is true
+func (s *PresenceService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Clients(r.URL.Query().Get("stale") == "true"))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+//GroupStatus represents the published state of a group
+type GroupStatus struct {
+	Version uint64 `json:",omitempty"` //0 if the group has never been published
+	Files   int
+	Error   string `json:",omitempty"` //the error if the group failed to walk in the last reload
+	Pinned  bool   `json:",omitempty"` //true if the group was rolled back and is held at Version until its origins change
+}
+
+//Status represents the state of the server
+type Status struct {
+	Groups           map[string]*GroupStatus //group:GroupStatus
+	LastReload       time.Time
+	LastReloadError  string `json:",omitempty"`
+	ConnectedClients int
+}
+
+//StatusService reports the state of the server
+type StatusService struct {
+	files    *FileService
+	notify   *NotifyService
+	presence *PresenceService
+}
+
+//NewStatusService returns a new StatusService
+func NewStatusService(files *FileService, notify *NotifyService, presence *PresenceService) *StatusService {
+	return &StatusService{files: files, notify: notify, presence: presence}
+}
+
+//Status returns the current Status
+func (s *StatusService) Status() *Status {
+	st := &Status{Groups: make(map[string]*GroupStatus), ConnectedClients: s.presence.Connected()}
+	for group, vs := range s.files.AllSets() {
+		st.Groups[group] = &GroupStatus{Version: vs.Version, Files: len(vs.Set)}
+	}
+	for group := range s.files.Pinned() {
+		if gs, ok := st.Groups[group]; ok {
+			gs.Pinned = true
+		}
+	}
+	for group, err := range s.files.Errors() {
+		if _, ok := st.Groups[group]; !ok {
+			st.Groups[group] = new(GroupStatus)
+		}
+		st.Groups[group].Error = err
+	}
+
+	var err error
+	if st.LastReload, err = s.notify.LastReload(); err != nil {
+		st.LastReloadError = err.Error()
+	}
+	return st
+}
+
+//ServeHTTP satisfies http.Handler, returning the Status in JSON
+func (s *StatusService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Status())
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+//TLSConfig returns the *tls.Config used by the HTTP and RPC servers, or nil if TLS isn't configured.
+//If TLSClientCA is configured, client certificates are verified against it if given, and are required if TLSRequireClientCert is set.
+//Clients without a certificate must be authenticated with a token by the policy and AdminAuth
+func TLSConfig(config *Config) (*tls.Config, error) {
+	if config.TLSCert == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading certificate: %v", err)
+	}
+	c := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if config.TLSClientCA != "" {
+		buf, err := ioutil.ReadFile(config.TLSClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading client CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(buf) {
+			return nil, fmt.Errorf("Error reading client CA: no certificates found in %s", config.TLSClientCA)
+		}
+		c.ClientCAs = pool
+		c.ClientAuth = tls.VerifyClientCertIfGiven
+		if config.TLSRequireClientCert {
+			c.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/korylprince/jettison/lib/file"
+)
+
+//removalWarnPercent is the percentage of a group's files that can be removed without a warning
+const removalWarnPercent = 50
+
+//contains returns true if path is dir or under dir
+func contains(dir, path string) bool {
+	dir, path = filepath.Clean(dir), filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+//validate returns warnings, sorted, about d and the change from the published sets to the walked sets:
+//origins in a group whose destinations overlap, empty groups, groups removing a large share of their files,
+//and paths delivered with different content or metadata by different groups
+func validate(d *file.Definition, published, walked map[string]*file.VersionedSet) []string {
+	var warnings []string
+
+	for group, mapping := range d.Groups {
+		origins := make([]string, 0, len(mapping))
+		for origin := range mapping {
+			origins = append(origins, origin)
+		}
+		sort.Strings(origins)
+		for i, o1 := range origins {
+			for _, o2 := range origins[i+1:] {
+				if contains(mapping[o1].Dest, mapping[o2].Dest) || contains(mapping[o2].Dest, mapping[o1].Dest) {
+					warnings = append(warnings, fmt.Sprintf("Group %s, Origins %s and %s: destinations %s and %s overlap",
+						group, o1, o2, mapping[o1].Dest, mapping[o2].Dest))
+				}
+			}
+		}
+	}
+
+	for group, vs := range walked {
+		if len(vs.Set) == 0 {
+			warnings = append(warnings, fmt.Sprintf("Group %s: no files", group))
+		}
+		old, ok := published[group]
+		if !ok || len(old.Set) == 0 {
+			continue
+		}
+		removed := 0
+		for path := range old.Set {
+			if _, ok := vs.Set[path]; !ok {
+				removed++
+			}
+		}
+		if removed*100 > len(old.Set)*removalWarnPercent {
+			warnings = append(warnings, fmt.Sprintf("Group %s: removes %d of %d files", group, removed, len(old.Set)))
+		}
+	}
+
+	paths := make(map[string]string) //path:first group delivering path
+	groups := make([]string, 0, len(walked))
+	for group := range walked {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		for path, entry := range walked[group].Set {
+			other, ok := paths[path]
+			if !ok {
+				paths[path] = group
+				continue
+			}
+			if *walked[other].Set[path] != *entry {
+				warnings = append(warnings, fmt.Sprintf("Path %s: differs between groups %s and %s", path, other, group))
+			}
+		}
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
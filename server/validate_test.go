@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/korylprince/jettison/lib/file"
+)
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		dir, path string
+		contains  bool
+	}{
+		{"/etc", "/etc", true},
+		{"/etc/", "/etc", true},
+		{"/etc", "/etc/ssh/sshd_config", true},
+		{"/", "/etc", true},
+		{"/etc", "/etcetera", false},
+		{"/etc/ssh", "/etc", false},
+	}
+
+	for _, test := range tests {
+		if c := contains(test.dir, test.path); c != test.contains {
+			t.Errorf("contains(%q, %q): expected %v, got %v", test.dir, test.path, test.contains, c)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	vs := func(s file.Set) *file.VersionedSet { return file.NewVersionedSet(s) }
+	def := func(groups map[string]map[string]*file.Mapping) *file.Definition {
+		return &file.Definition{Version: file.DefinitionVersion, Groups: groups}
+	}
+	four := file.Set{"/a": {Hash: 1}, "/b": {Hash: 2}, "/c": {Hash: 3}, "/d": {Hash: 4}}
+
+	tests := []struct {
+		name      string
+		def       *file.Definition
+		published map[string]*file.VersionedSet
+		walked    map[string]*file.VersionedSet
+		warnings  []string
+	}{
+		{"clean", def(map[string]map[string]*file.Mapping{"base": {"/srv/a": {Dest: "/etc/a"}, "/srv/b": {Dest: "/etc/b"}}}),
+			map[string]*file.VersionedSet{"base": vs(four)},
+			map[string]*file.VersionedSet{"base": vs(four)},
+			nil},
+		{"overlapping origins", def(map[string]map[string]*file.Mapping{"base": {"/srv/a": {Dest: "/etc"}, "/srv/b": {Dest: "/etc/b"}}}),
+			nil,
+			map[string]*file.VersionedSet{"base": vs(four)},
+			[]string{"Group base, Origins /srv/a and /srv/b: destinations /etc and /etc/b overlap"}},
+		{"empty group", def(nil),
+			nil,
+			map[string]*file.VersionedSet{"base": vs(file.Set{})},
+			[]string{"Group base: no files"}},
+		{"removes half", def(nil),
+			map[string]*file.VersionedSet{"base": vs(four)},
+			map[string]*file.VersionedSet{"base": vs(file.Set{"/a": {Hash: 1}, "/b": {Hash: 2}})},
+			nil},
+		{"removes most", def(nil),
+			map[string]*file.VersionedSet{"base": vs(four)},
+			map[string]*file.VersionedSet{"base": vs(file.Set{"/a": {Hash: 1}})},
+			[]string{"Group base: removes 3 of 4 files"}},
+		{"same path same content", def(nil),
+			nil,
+			map[string]*file.VersionedSet{"base": vs(file.Set{"/a": {Hash: 1}}), "extra": vs(file.Set{"/a": {Hash: 1}})},
+			nil},
+		{"same path different content", def(nil),
+			nil,
+			map[string]*file.VersionedSet{"base": vs(file.Set{"/a": {Hash: 1}}), "extra": vs(file.Set{"/a": {Hash: 2}})},
+			[]string{"Path /a: differs between groups base and extra"}},
+		{"same path different metadata", def(nil),
+			nil,
+			map[string]*file.VersionedSet{"base": vs(file.Set{"/a": {Hash: 1}}), "extra": vs(file.Set{"/a": {Hash: 1, Mode: 0600}})},
+			[]string{"Path /a: differs between groups base and extra"}},
+		{"sorted", def(nil),
+			map[string]*file.VersionedSet{"base": vs(four)},
+			map[string]*file.VersionedSet{"base": vs(file.Set{}), "extra": vs(file.Set{})},
+			[]string{"Group base: no files", "Group base: removes 4 of 4 files", "Group extra: no files"}},
+	}
+
+	for _, test := range tests {
+		if w := validate(test.def, test.published, test.walked); !reflect.DeepEqual(w, test.warnings) {
+			t.Errorf("%s: expected %q, got %q", test.name, test.warnings, w)
+		}
+	}
+}
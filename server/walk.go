@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,10 +14,16 @@ import (
 	"github.com/korylprince/jettison/lib/file"
 )
 
+//fileModeMask is the part of os.FileMode delivered to clients
+const fileModeMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
 //fileInfo represents metadata about a file
 type fileInfo struct {
 	Hash    uint64
+	Digest  string
+	Mode    os.FileMode
 	ModTime time.Time
+	Size    int64
 	Path    string
 }
 
@@ -30,42 +37,71 @@ func renamePath(path, origin, dest string) string {
 	return filepath.Join(dest, p)
 }
 
-//WalkDefinition walks d, returning all, a Set with origin paths, mapped a map of Sets with destination paths split by groups,
-//or an error if one occurred. WalkDefinition will use cache as hash cache and workers for the number of workers.
-func WalkDefinition(ctx context.Context, d file.Definition, c cache.Cache, workers int) (all file.Set, mapped map[string]*file.VersionedSet, err error) {
-	m := make(map[string]*file.VersionedSet)
-	all = make(file.Set)
-	for group, mapping := range d {
-		m[group] = &file.VersionedSet{Set: make(file.Set), Version: 0}
-		for origin, dest := range mapping {
-			s := make(file.Set)
-
-			err := walkRoot(ctx, c, s, origin, workers)
-			if err != nil {
-				return nil, nil, err
+//WalkDefinition walks d, returning origins, a mapping of hashes to origin paths, mapped a map of Sets with destination paths split by groups,
+//or an error if one occurred. WalkDefinition will use cache as hash cache, h (if not nil) to compute digests,
+//and workers for the number of workers.
+//If tolerant is true, groups that fail to walk are left out of origins and mapped, and their errors are returned in failed instead
+func WalkDefinition(ctx context.Context, d *file.Definition, c cache.Cache, h file.Hasher, workers int, tolerant bool) (origins map[uint64]string, mapped map[string]*file.VersionedSet, failed map[string]error, err error) {
+	mapped = make(map[string]*file.VersionedSet)
+	origins = make(map[uint64]string)
+	failed = make(map[string]error)
+	for group, mapping := range d.Groups {
+		o, vs, err := walkGroup(ctx, mapping, c, h, workers)
+		if err != nil {
+			if !tolerant {
+				return nil, nil, nil, err
 			}
+			failed[group] = err
+			continue
+		}
+		for hash, path := range o {
+			origins[hash] = path
+		}
+		mapped[group] = vs
+	}
+	return origins, mapped, failed, nil
+}
 
-			//copy to all
-			for hash, path := range s {
-				all[hash] = path
+//walkGroup walks the origins in mapping, returning origins, a mapping of hashes to origin paths,
+//and the VersionedSet with destination paths, or an error if one occurred
+func walkGroup(ctx context.Context, mapping map[string]*file.Mapping, c cache.Cache, h file.Hasher, workers int) (origins map[uint64]string, vs *file.VersionedSet, err error) {
+	origins = make(map[uint64]string)
+	set := make(file.Set)
+	for origin, m := range mapping {
+		//s is keyed by origin path
+		s := make(file.Set)
+
+		err := walkRoot(ctx, c, h, s, origin, m, workers)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mode, setMode, _ := m.FileMode() //validated by file.Parse
+
+		for path, entry := range s {
+			//any origin with the same content can be served
+			origins[entry.Hash] = path
+
+			if setMode {
+				entry.Mode = mode
 			}
+			entry.Owner = m.Owner
+			entry.Group = m.Group
+			entry.Delete = m.Delete
 
 			//rewrite paths
-			for hash, path := range s {
-				//origin is file
-				if origin == path {
-					m[group].Set[hash] = dest
-				} else {
-					m[group].Set[hash] = renamePath(path, origin, dest)
-				}
-				m[group].Version += hash
+			//origin is file
+			if origin == path {
+				set[m.Dest] = entry
+			} else {
+				set[renamePath(path, origin, m.Dest)] = entry
 			}
 		}
 	}
-	return all, m, nil
+	return origins, file.NewVersionedSet(set), nil
 }
 
-func walkRoot(ctx context.Context, c cache.Cache, s file.Set, root string, workers int) error {
+func walkRoot(ctx context.Context, c cache.Cache, h file.Hasher, s file.Set, root string, m *file.Mapping, workers int) error {
 	rootctx, rootCancel := context.WithCancel(ctx)
 	accctx, accCancel := context.WithCancel(ctx)
 	infos := make(chan *fileInfo)
@@ -75,16 +111,21 @@ func walkRoot(ctx context.Context, c cache.Cache, s file.Set, root string, worke
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		rerr = rootWalker(rootctx, root, infos)
+		rerr = rootWalker(rootctx, root, m, infos)
 		if rerr != nil {
 			accCancel()
 		}
 	}()
 
-	err := accumulator(accctx, s, c, infos, workers)
+	err := accumulator(accctx, s, c, h, infos, workers)
 
 	if err != nil {
 		rootCancel()
+		wg.Wait()
+		//the accumulator was cancelled because of the walker's error
+		if rerr != nil {
+			return rerr
+		}
 		return err
 	}
 
@@ -92,30 +133,66 @@ func walkRoot(ctx context.Context, c cache.Cache, s file.Set, root string, worke
 	return rerr
 }
 
-//rootWalker passes an *Info for every path under root to out, returning the
-//first error encountered, if any. If ctx is cancelled, rootWalker returns at earliest opportunity
-func rootWalker(ctx context.Context, root string, out chan<- *fileInfo) error {
+//excluded returns true if the slash separated path, relative to an origin, is skipped by m's include and exclude patterns.
+//isDir should be true if the path is a directory. Directories are only skipped by exclude patterns
+func excluded(m *file.Mapping, rel string, isDir bool) bool {
+	if isDir {
+		return m.Exclude.Match(rel, true)
+	}
+	return m.Exclude.Match(rel, false) || (len(m.Include) > 0 && !m.Include.MatchParents(rel, false))
+}
+
+//rootWalker passes an *Info for every path under root allowed by m's include and exclude patterns to out,
+//returning the first error encountered, if any. Excluded directories are not walked.
+//If ctx is cancelled, rootWalker returns at earliest opportunity
+func rootWalker(ctx context.Context, root string, m *file.Mapping, out chan<- *fileInfo) error {
 	defer close(out)
 	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return fmt.Errorf("Error walking path %s: %v", path, err)
 		}
 
+		//patterns are matched against the path relative to root, or the file name if root is a file
+		rel := filepath.Base(path)
+		if path != root {
+			if rel, err = filepath.Rel(root, path); err != nil {
+				return fmt.Errorf("Error walking path %s: %v", path, err)
+			}
+			rel = filepath.ToSlash(rel)
+		}
+
+		if info.IsDir() {
+			if path != root && excluded(m, rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 && m.Symlinks == file.SymlinkFollow {
+			if info, err = os.Stat(path); err != nil {
+				return fmt.Errorf("Error following symlink %s: %v", path, err)
+			}
+		}
+
 		if !info.Mode().IsRegular() {
 			return nil
 		}
 
+		if excluded(m, rel, false) {
+			return nil
+		}
+
 		select {
 		case <-ctx.Done(): //cancelled
 			return ctx.Err()
-		case out <- &fileInfo{Path: path, ModTime: info.ModTime()}:
+		case out <- &fileInfo{Path: path, Mode: info.Mode() & fileModeMask, ModTime: info.ModTime(), Size: info.Size()}:
 			return nil
 		}
 	})
 }
 
-//Accumulator adds hashed paths given on in to set.
-func accumulator(ctx context.Context, set file.Set, c cache.Cache, in <-chan *fileInfo, workers int) error {
+//Accumulator adds hashed paths given on in to set, keyed by path.
+func accumulator(ctx context.Context, set file.Set, c cache.Cache, h file.Hasher, in <-chan *fileInfo, workers int) error {
 
 	wg := new(sync.WaitGroup)
 	out := make(chan *fileInfo, workers)
@@ -138,11 +215,11 @@ func accumulator(ctx context.Context, set file.Set, c cache.Cache, in <-chan *fi
 				if !ok {
 					return
 				}
-				set[info.Hash] = info.Path
+				set[info.Path] = &file.Entry{Hash: info.Hash, Digest: info.Digest, Mode: info.Mode}
 			}
 		}
 	}()
-	concurrentHasher(sub, c, in, out, errors, workers)
+	concurrentHasher(sub, c, h, in, out, errors, workers)
 	wg.Wait()
 
 	return err
@@ -158,9 +235,9 @@ func sendError(ctx context.Context, errors chan<- error, err error) {
 	}
 }
 
-//hasher takes *Infos from in and outputs them to out after computing the hash or getting it from the cache
+//hasher takes *Infos from in and outputs them to out after computing the hash (and digest if h is not nil) or getting it from the cache
 //errors are sent on errors. If ctx is cancelled, hasher will exit at the earliest opportunity
-func hasher(ctx context.Context, wg *sync.WaitGroup, c cache.Cache, in <-chan *fileInfo, out chan<- *fileInfo, errors chan<- error) {
+func hasher(ctx context.Context, wg *sync.WaitGroup, c cache.Cache, h file.Hasher, in <-chan *fileInfo, out chan<- *fileInfo, errors chan<- error) {
 	defer wg.Done()
 	for {
 		select {
@@ -173,9 +250,10 @@ func hasher(ctx context.Context, wg *sync.WaitGroup, c cache.Cache, in <-chan *f
 				return
 			}
 
-			//check cache
-			hash, mtime, err := c.Get(info.Path)
-			if err == nil && !info.ModTime.After(mtime) {
+			//check cache, making sure digest was computed with the same algorithm
+			e, err := c.Get(info.Path)
+			if err == nil && !info.ModTime.After(e.ModTime) && (e.Size < 0 || e.Size == info.Size) &&
+				(h == nil || strings.HasPrefix(e.Digest, h.Name()+":")) {
 				goto sendHash
 			}
 			if err != nil && err != cache.ErrorInvalidCacheEntry {
@@ -184,14 +262,15 @@ func hasher(ctx context.Context, wg *sync.WaitGroup, c cache.Cache, in <-chan *f
 			}
 
 			//compute hash
-			hash, err = file.Hash(info.Path)
+			e = &cache.Entry{ModTime: info.ModTime, Size: info.Size}
+			e.Hash, e.Digest, err = file.HashDigest(info.Path, h)
 			if err != nil {
 				sendError(ctx, errors, fmt.Errorf("Error hashing %s: %v", info.Path, err))
 				return
 			}
 
 			//store hash in cache
-			err = c.Put(info.Path, hash, info.ModTime)
+			err = c.Put(info.Path, e)
 			if err != nil {
 				sendError(ctx, errors, fmt.Errorf("Error putting cache entry %s: %v", info.Path, err))
 				return
@@ -199,7 +278,10 @@ func hasher(ctx context.Context, wg *sync.WaitGroup, c cache.Cache, in <-chan *f
 
 		sendHash:
 
-			info.Hash = hash
+			info.Hash = e.Hash
+			if h != nil {
+				info.Digest = e.Digest
+			}
 
 			select {
 			case <-ctx.Done(): //cancelled
@@ -210,16 +292,16 @@ func hasher(ctx context.Context, wg *sync.WaitGroup, c cache.Cache, in <-chan *f
 	}
 }
 
-//concurrentHasher takes *Infos from in and outputs them to out after computing the hash or getting it from the cache
+//concurrentHasher takes *Infos from in and outputs them to out after computing the hash (and digest if h is not nil) or getting it from the cache
 //workers specifies how many hasher goroutines are run at once.
 //errors are sent on errors. If ctx is cancelled, concurrentHasher will exit at the earliest opportunity
-func concurrentHasher(ctx context.Context, c cache.Cache, in <-chan *fileInfo, out chan<- *fileInfo, errors chan<- error, workers int) {
+func concurrentHasher(ctx context.Context, c cache.Cache, h file.Hasher, in <-chan *fileInfo, out chan<- *fileInfo, errors chan<- error, workers int) {
 	defer close(out)
 
 	wg := new(sync.WaitGroup)
 	wg.Add(workers)
 	for i := 0; i < workers; i++ {
-		go hasher(ctx, wg, c, in, out, errors)
+		go hasher(ctx, wg, c, h, in, out, errors)
 	}
 
 	wg.Wait() //wait for hashers to exit
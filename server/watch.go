@@ -0,0 +1,168 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/korylprince/jettison/lib/file"
+)
+
+//Watcher reloads the NotifyService when the definition or any origin path changes
+type Watcher struct {
+	config  *Config
+	notify  *NotifyService
+	watcher *fsnotify.Watcher
+	watched map[string]struct{}       //set{directories}
+	dirs    map[string][]*watchedRoot //directory:origins containing it, where changes not excluded by the origin are relevant
+	files   map[string]struct{}       //set{files whose directory is watched only for them}
+}
+
+//watchedRoot is an origin directory and its mapping
+type watchedRoot struct {
+	root    string
+	mapping *file.Mapping
+}
+
+//NewWatcher returns a new Watcher or an error if one occurred
+func NewWatcher(config *Config, notify *NotifyService) (*Watcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		config:  config,
+		notify:  notify,
+		watcher: w,
+		watched: make(map[string]struct{}),
+		dirs:    make(map[string][]*watchedRoot),
+		files:   make(map[string]struct{}),
+	}, nil
+}
+
+//rewatch replaces the watched paths with the directory of the definition and
+//every directory under every origin in the current definition, skipping excluded directories
+func (w *Watcher) rewatch() {
+	w.dirs = make(map[string][]*watchedRoot)
+	w.files = map[string]struct{}{filepath.Clean(w.config.DefinitionPath): {}}
+
+	def, err := file.Parse(w.config.DefinitionPath)
+	if err != nil {
+		log.Println("Watcher: Error parsing definition:", err)
+		def = new(file.Definition)
+	}
+	for _, mapping := range def.Groups {
+		for origin, m := range mapping {
+			//watch the parent of file origins so replaced files are noticed
+			if info, err := os.Stat(origin); err == nil && !info.IsDir() {
+				w.files[filepath.Clean(origin)] = struct{}{}
+				continue
+			}
+			wr := &watchedRoot{root: filepath.Clean(origin), mapping: m}
+			filepath.Walk(origin, func(path string, info os.FileInfo, err error) error {
+				if err != nil || !info.IsDir() {
+					return nil
+				}
+				path = filepath.Clean(path)
+				if path != wr.root {
+					if rel, err := filepath.Rel(wr.root, path); err == nil && excluded(m, filepath.ToSlash(rel), true) {
+						return filepath.SkipDir
+					}
+				}
+				w.dirs[path] = append(w.dirs[path], wr)
+				return nil
+			})
+		}
+	}
+
+	paths := make(map[string]struct{})
+	for path := range w.dirs {
+		paths[path] = struct{}{}
+	}
+	for path := range w.files {
+		paths[filepath.Dir(path)] = struct{}{}
+	}
+
+	for path := range w.watched {
+		if _, ok := paths[path]; !ok {
+			w.watcher.Remove(path) //path may no longer exist
+			delete(w.watched, path)
+		}
+	}
+	for path := range paths {
+		if _, ok := w.watched[path]; ok {
+			continue
+		}
+		if err := w.watcher.Add(path); err != nil {
+			log.Printf("Watcher: Error watching %s: %v\n", path, err)
+			continue
+		}
+		w.watched[path] = struct{}{}
+	}
+}
+
+//relevant returns true if event could change the definition or any origin.
+//Changes to paths excluded by every origin containing them aren't relevant
+func (w *Watcher) relevant(event fsnotify.Event) bool {
+	if _, ok := w.files[event.Name]; ok {
+		return true
+	}
+	roots, ok := w.dirs[filepath.Dir(event.Name)]
+	if !ok {
+		return false
+	}
+
+	//removed paths can't be checked, so they're treated as files
+	isDir := false
+	if info, err := os.Stat(event.Name); err == nil {
+		isDir = info.IsDir()
+	}
+	for _, wr := range roots {
+		rel, err := filepath.Rel(wr.root, event.Name)
+		if err != nil || !excluded(wr.mapping, filepath.ToSlash(rel), isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+//Watch watches for changes, reloading after no changes have been seen for the configured delay.
+//Watch blocks until the underlying watcher is closed
+func (w *Watcher) Watch() {
+	w.rewatch()
+	log.Printf("Watcher: Watching %d directories\n", len(w.watched))
+
+	var reload <-chan time.Time
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !w.relevant(event) {
+				continue
+			}
+			//new directories are picked up by rewatch after reloading
+			reload = time.After(w.config.WatchDelay * time.Second)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println("Watcher: Error:", err)
+		case <-reload:
+			reload = nil
+			log.Println("Watcher: Change detected, reloading")
+			if _, err := w.notify.Reload(); err != nil {
+				log.Println("Watcher:", err)
+			}
+			w.rewatch()
+		}
+	}
+}
+
+//Close closes the underlying watcher
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
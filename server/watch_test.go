@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/korylprince/jettison/lib/file"
+)
+
+func TestWatcherRelevant(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jettison-watch-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = os.Mkdir(filepath.Join(dir, "cache"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	root := &watchedRoot{root: dir, mapping: &file.Mapping{
+		Include: file.Patterns{"*.conf", "cache/"},
+		Exclude: file.Patterns{"*.swp", "cache/"},
+	}}
+	w := &Watcher{
+		dirs:  map[string][]*watchedRoot{dir: {root}},
+		files: map[string]struct{}{"/etc/jettison.json": {}},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		relevant bool
+	}{
+		{"definition", "/etc/jettison.json", true},
+		{"unwatched directory", "/etc/other.conf", false},
+		{"included", filepath.Join(dir, "a.conf"), true},
+		{"excluded", filepath.Join(dir, "a.conf.swp"), false},
+		{"not included", filepath.Join(dir, "a.txt"), false},
+		{"excluded directory", filepath.Join(dir, "cache"), false},
+	}
+
+	for _, test := range tests {
+		if r := w.relevant(fsnotify.Event{Name: test.path}); r != test.relevant {
+			t.Errorf("%s: expected %v, got %v", test.name, test.relevant, r)
+		}
+	}
+
+	//a second origin covering the same directory without patterns makes every change relevant
+	w.dirs[dir] = append(w.dirs[dir], &watchedRoot{root: dir, mapping: new(file.Mapping)})
+	if !w.relevant(fsnotify.Event{Name: filepath.Join(dir, "a.txt")}) {
+		t.Error("second origin: expected true, got false")
+	}
+}